@@ -1,11 +1,12 @@
 module github.com/princespaghetti/verifi
 
-go 1.25.2
+go 1.26.0
 
 require (
 	github.com/gofrs/flock v0.13.0
 	github.com/spf13/cobra v1.10.1
 	github.com/stretchr/testify v1.11.1
+	software.sslmate.com/src/go-pkcs12 v0.5.0
 )
 
 require (
@@ -15,6 +16,7 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.14.1 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
-	golang.org/x/sys v0.37.0 // indirect
+	golang.org/x/crypto v0.57.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
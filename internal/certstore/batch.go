@@ -0,0 +1,227 @@
+package certstore
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// CertNameSource selects how AddCerts names certificates.
+type CertNameSource string
+
+// Certificate name sources.
+const (
+	NameFromFile CertNameSource = "file" // file name without extension
+	NameFromCN   CertNameSource = "cn"   // common name of the first certificate
+)
+
+// BatchAdded describes a certificate added by AddCerts.
+type BatchAdded struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Subject string `json:"subject"`
+}
+
+// BatchSkipped describes a file that AddCerts did not add, and why.
+type BatchSkipped struct {
+	File   string `json:"file"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+	Err    error  `json:"-"`
+}
+
+// BatchAddResult reports the outcome of AddCerts.
+type BatchAddResult struct {
+	Added   []BatchAdded   `json:"added"`
+	Skipped []BatchSkipped `json:"skipped"`
+}
+
+// DeriveCertName turns a file name or common name into a certificate name:
+// lower case, with runs of anything but letters, digits, '.', '_' and '-'
+// replaced by '-'. It returns "" if nothing usable is left.
+func DeriveCertName(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.':
+			b.WriteRune(r)
+			dash = false
+		default:
+			if !dash {
+				b.WriteRune('-')
+				dash = true
+			}
+		}
+	}
+	name := strings.Trim(b.String(), "-.")
+	for strings.Contains(name, "..") {
+		name = strings.ReplaceAll(name, "..", ".")
+	}
+	return name
+}
+
+// CertNameFor derives the name of a certificate file from nameFrom, falling
+// back to the file name when the certificate is nil or has no common name.
+func CertNameFor(path string, cert *x509.Certificate, nameFrom CertNameSource) string {
+	if nameFrom == NameFromCN && cert != nil {
+		if name := DeriveCertName(cert.Subject.CommonName); name != "" {
+			return name
+		}
+	}
+	return DeriveCertName(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+}
+
+// findFingerprint returns the value recorded in seen for the first of metas
+// whose fingerprint it contains.
+func findFingerprint(seen map[string]string, metas []*CertMetadata) (string, bool) {
+	for _, meta := range metas {
+		if value, ok := seen[meta.Fingerprint]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// batchCert is a validated file waiting to be committed by AddCerts.
+type batchCert struct {
+	file   string
+	name   string
+	certs  []*x509.Certificate
+	metas  []*CertMetadata
+	format CertFormat
+}
+
+// AddCerts adds several certificate files in one transaction. Every file is
+// validated first; files that fail validation, or whose name or content
+// clashes with another file or an existing certificate, are skipped and
+// reported. The remaining files are then written and recorded in a single
+// transaction with a single bundle rebuild, all or nothing: if anything
+// fails while committing, none of them are added.
+//
+// Certificates are named from their file or common name (see nameFrom).
+// opts applies to every file.
+func (s *Store) AddCerts(ctx context.Context, files []string, opts AddCertOptions, nameFrom CertNameSource) (*BatchAddResult, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "add certificates",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	// Refuse to touch a store written by a newer verifi
+	if err := s.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	if !opts.TrustUntil.IsZero() && !opts.TrustUntil.After(time.Now()) {
+		return nil, &verifierrors.VerifiError{
+			Op:  "add certificates",
+			Err: fmt.Errorf("trust expiry %s is in the past", opts.TrustUntil.Format(time.RFC3339)),
+		}
+	}
+
+	result := &BatchAddResult{}
+	skip := func(file, name string, err error) {
+		result.Skipped = append(result.Skipped, BatchSkipped{File: file, Name: name, Reason: err.Error(), Err: err})
+	}
+
+	// Validate everything before taking the lock
+	var pending []batchCert
+	names := make(map[string]string)
+	fingerprints := make(map[string]string)
+	for _, file := range files {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		certs, metas, format, err := s.loadCertFile(file, opts)
+		if err != nil {
+			skip(file, "", err)
+			continue
+		}
+
+		name := CertNameFor(file, certs[0], nameFrom)
+		if err := validateCertName("add certificate", name); err != nil {
+			skip(file, name, err)
+			continue
+		}
+		if other, ok := names[name]; ok {
+			skip(file, name, fmt.Errorf("name '%s' is also derived from %s", name, other))
+			continue
+		}
+		if other, ok := findFingerprint(fingerprints, metas); ok {
+			skip(file, name, fmt.Errorf("%w: same certificate as %s", verifierrors.ErrCertExists, other))
+			continue
+		}
+		names[name] = file
+		for _, meta := range metas {
+			fingerprints[meta.Fingerprint] = file
+		}
+
+		pending = append(pending, batchCert{file: file, name: name, certs: certs, metas: metas, format: format})
+	}
+
+	err := s.update(ctx, "add certificates", func(tx *storeTx) error {
+		md := tx.md
+		existingNames := make(map[string]bool)
+		existingFingerprints := make(map[string]string)
+		for _, info := range md.UserCerts {
+			existingNames[info.Name] = true
+			existingFingerprints[info.Fingerprint] = info.Name
+			for _, c := range info.Certificates {
+				existingFingerprints[c.Fingerprint] = info.Name
+			}
+		}
+
+		now := time.Now()
+		for _, p := range pending {
+			if existingNames[p.name] {
+				skip(p.file, p.name, fmt.Errorf("%w: '%s'", verifierrors.ErrCertExists, p.name))
+				continue
+			}
+			if existing, ok := findFingerprint(existingFingerprints, p.metas); ok {
+				skip(p.file, p.name, fmt.Errorf("%w: same certificate as '%s'", verifierrors.ErrCertExists, existing))
+				continue
+			}
+
+			destPath := s.userCertPath(p.name)
+			if _, err := s.fs.Stat(destPath); err == nil {
+				skip(p.file, p.name, fmt.Errorf("an untracked file already exists at %s", destPath))
+				continue
+			}
+			if err := tx.writeFile(destPath, EncodeCertsPEM(p.certs)); err != nil {
+				return err
+			}
+
+			info := newUserCertInfo(p.name, p.certs, p.metas)
+			info.Added = now
+			info.SourceFormat = p.format
+			info.Tags = normalizeTags(opts.Tags)
+			info.Note = strings.TrimSpace(opts.Note)
+			info.Owner = strings.TrimSpace(opts.Owner)
+			info.TrustUntil = opts.TrustUntil
+			md.UserCerts = append(md.UserCerts, info)
+
+			result.Added = append(result.Added, BatchAdded{Name: p.name, File: p.file, Subject: info.Subject})
+		}
+
+		if len(result.Added) == 0 {
+			return nil
+		}
+		return s.RebuildBundle(ctx, md)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package certstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+func TestDeriveCertName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "corp-root", want: "corp-root"},
+		{in: "Corp Root CA 2026", want: "corp-root-ca-2026"},
+		{in: "ACME, Inc. (Issuing)", want: "acme-inc.-issuing"},
+		{in: "..hidden", want: "hidden"},
+		{in: "a..b", want: "a.b"},
+		{in: "***", want: ""},
+	}
+	for _, tt := range tests {
+		if got := DeriveCertName(tt.in); got != tt.want {
+			t.Errorf("DeriveCertName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStore_AddCerts(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+	srcDir := filepath.Join(tmpDir, "src")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+
+	now := time.Now()
+	write := func(file string, data []byte) string {
+		t.Helper()
+		path := filepath.Join(srcDir, file)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		return path
+	}
+
+	vpnPEM := generateTestCert(t, "VPN Root", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+	proxyPEM := generateTestCert(t, "Proxy CA", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+	files := []string{
+		write("vpn root.pem", vpnPEM),
+		write("vpn-copy.crt", vpnPEM),
+		write("proxy.pem", proxyPEM),
+		write("expired.pem", generateTestCert(t, "Expired CA", now.Add(-48*time.Hour), now.Add(-24*time.Hour))),
+		write("garbage.pem", []byte("not a certificate")),
+		write("corp.pem", generateTestCert(t, "Another Corp", now.Add(-time.Hour), now.Add(365*24*time.Hour))),
+	}
+
+	result, err := store.AddCerts(ctx, files, AddCertOptions{Tags: []string{"batch"}}, NameFromFile)
+	if err != nil {
+		t.Fatalf("AddCerts() error = %v", err)
+	}
+
+	added := make(map[string]bool)
+	for _, a := range result.Added {
+		added[a.Name] = true
+	}
+	if len(result.Added) != 2 || !added["vpn-root"] || !added["proxy"] {
+		t.Errorf("Added = %+v, want vpn-root and proxy", result.Added)
+	}
+
+	skipped := make(map[string]error)
+	for _, s := range result.Skipped {
+		skipped[filepath.Base(s.File)] = s.Err
+	}
+	if len(skipped) != 4 {
+		t.Errorf("Skipped = %+v, want 4 files", result.Skipped)
+	}
+	if !errors.Is(skipped["vpn-copy.crt"], verifierrors.ErrCertExists) {
+		t.Errorf("duplicate content error = %v, want ErrCertExists", skipped["vpn-copy.crt"])
+	}
+	if !errors.Is(skipped["expired.pem"], verifierrors.ErrCertExpired) {
+		t.Errorf("expired error = %v, want ErrCertExpired", skipped["expired.pem"])
+	}
+	if skipped["garbage.pem"] == nil {
+		t.Error("garbage.pem should be skipped")
+	}
+	if !errors.Is(skipped["corp.pem"], verifierrors.ErrCertExists) {
+		t.Errorf("existing name error = %v, want ErrCertExists", skipped["corp.pem"])
+	}
+
+	info, err := store.GetCertInfo("vpn-root")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+	if len(info.Tags) != 1 || info.Tags[0] != "batch" {
+		t.Errorf("Tags = %v, want [batch]", info.Tags)
+	}
+	if !combinedBundleContains(t, store, "# Source: user:vpn-root") || !combinedBundleContains(t, store, "# Source: user:proxy") {
+		t.Error("combined bundle should contain the added certificates")
+	}
+	if combinedBundleContains(t, store, "Another Corp") {
+		t.Error("skipped certificate should not be in the combined bundle")
+	}
+
+	t.Run("names from common name", func(t *testing.T) {
+		path := write("ca.pem", generateTestCert(t, "Build Farm CA", now.Add(-time.Hour), now.Add(365*24*time.Hour)))
+		result, err := store.AddCerts(ctx, []string{path}, AddCertOptions{}, NameFromCN)
+		if err != nil {
+			t.Fatalf("AddCerts() error = %v", err)
+		}
+		if len(result.Added) != 1 || result.Added[0].Name != "build-farm-ca" {
+			t.Errorf("Added = %+v, want build-farm-ca", result.Added)
+		}
+	})
+
+	t.Run("duplicates anywhere in a chain file", func(t *testing.T) {
+		labPEM := generateTestCert(t, "Lab CA", now.Add(-time.Hour), now.Add(365*24*time.Hour))
+		chainFiles := []string{
+			write("lab.pem", labPEM),
+			// Second certificate is lab.pem, added in the same batch
+			write("lab-chain.pem", append(generateTestCert(t, "Lab Issuing CA", now.Add(-time.Hour), now.Add(365*24*time.Hour)), labPEM...)),
+			// Second certificate is proxy, already in the store
+			write("proxy-chain.pem", append(generateTestCert(t, "Proxy Issuing CA", now.Add(-time.Hour), now.Add(365*24*time.Hour)), proxyPEM...)),
+		}
+		result, err := store.AddCerts(ctx, chainFiles, AddCertOptions{}, NameFromFile)
+		if err != nil {
+			t.Fatalf("AddCerts() error = %v", err)
+		}
+		if len(result.Added) != 1 || result.Added[0].Name != "lab" {
+			t.Errorf("Added = %+v, want lab", result.Added)
+		}
+		if len(result.Skipped) != 2 {
+			t.Fatalf("Skipped = %+v, want lab-chain and proxy-chain", result.Skipped)
+		}
+		for _, s := range result.Skipped {
+			if !errors.Is(s.Err, verifierrors.ErrCertExists) {
+				t.Errorf("%s skipped with %v, want ErrCertExists", s.File, s.Err)
+			}
+		}
+	})
+
+	t.Run("re-running adds nothing", func(t *testing.T) {
+		result, err := store.AddCerts(ctx, files[:3], AddCertOptions{}, NameFromFile)
+		if err != nil {
+			t.Fatalf("AddCerts() error = %v", err)
+		}
+		if len(result.Added) != 0 {
+			t.Errorf("Added = %+v, want nothing", result.Added)
+		}
+		for _, s := range result.Skipped {
+			if !errors.Is(s.Err, verifierrors.ErrCertExists) {
+				t.Errorf("%s skipped with %v, want ErrCertExists", s.File, s.Err)
+			}
+		}
+	})
+}
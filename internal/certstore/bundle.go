@@ -1,38 +1,57 @@
 package certstore
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+	"github.com/princespaghetti/verifi/internal/fetcher"
 )
 
-// readUserCerts reads all PEM certificate files from the certs/user/ directory.
-// Returns a slice of certificate data (one entry per file).
+// userCertFile is the raw content of an enabled user certificate file.
+type userCertFile struct {
+	Name string
+	Path string
+	Data []byte
+}
+
+// readUserCerts reads the files of all enabled user certificates listed in
+// metadata. Returns a slice of certificate data (one entry per file).
 func (s *Store) readUserCerts(ctx context.Context) ([][]byte, error) {
-	// Check context before starting
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return nil, err
 	}
 
-	userCertsDir := filepath.Join(s.basePath, "certs", "user")
-
-	// Read directory contents
-	entries, err := s.fs.ReadDir(userCertsDir)
+	files, err := s.readUserCertFiles(ctx, metadata.UserCerts)
 	if err != nil {
-		return nil, &verifierrors.VerifiError{
-			Op:   "read user certs directory",
-			Path: userCertsDir,
-			Err:  err,
-		}
+		return nil, err
+	}
+
+	certData := make([][]byte, 0, len(files))
+	for _, file := range files {
+		certData = append(certData, file.Data)
 	}
+	return certData, nil
+}
 
-	var certData [][]byte
+// readUserCertFiles reads the files of the enabled entries in userCerts and
+// of their revisions that are still in a rotation overlap, keeping the path
+// of each file for error reporting. Disabled entries, entries whose temporary
+// trust has lapsed and files in certs/user/ without a metadata entry are
+// skipped. A missing file for an enabled entry is an error.
+func (s *Store) readUserCertFiles(ctx context.Context, userCerts []UserCertInfo) ([]userCertFile, error) {
+	var files []userCertFile
+	now := time.Now()
 
-	for _, entry := range entries {
+	for _, info := range userCerts {
 		// Check context periodically
 		select {
 		case <-ctx.Done():
@@ -40,16 +59,12 @@ func (s *Store) readUserCerts(ctx context.Context) ([][]byte, error) {
 		default:
 		}
 
-		// Skip directories and non-.pem files
-		if entry.IsDir() {
-			continue
-		}
-		if !strings.HasSuffix(entry.Name(), ".pem") {
+		if info.Disabled || info.TrustLapsed(now) {
 			continue
 		}
 
 		// Read certificate file
-		certPath := filepath.Join(userCertsDir, entry.Name())
+		certPath := filepath.Join(s.basePath, "certs", info.Path)
 		data, err := s.fs.ReadFile(certPath)
 		if err != nil {
 			return nil, &verifierrors.VerifiError{
@@ -59,10 +74,241 @@ func (s *Store) readUserCerts(ctx context.Context) ([][]byte, error) {
 			}
 		}
 
-		certData = append(certData, data)
+		files = append(files, userCertFile{Name: info.Name, Path: certPath, Data: data})
+
+		for _, rev := range info.Revisions {
+			if !rev.Active(now) {
+				continue
+			}
+			revPath := filepath.Join(s.basePath, "certs", rev.Path)
+			data, err := s.fs.ReadFile(revPath)
+			if err != nil {
+				return nil, &verifierrors.VerifiError{
+					Op:   "read retiring certificate",
+					Path: revPath,
+					Err:  err,
+				}
+			}
+			files = append(files, userCertFile{Name: retiringName(info.Name), Path: revPath, Data: data})
+		}
 	}
 
-	return certData, nil
+	return files, nil
+}
+
+// bundleEntry is a certificate in the combined bundle together with every
+// source that provided it.
+type bundleEntry struct {
+	Cert    *x509.Certificate
+	Sources []string
+}
+
+// bundleSource is a source file of the combined bundle and its SHA256 hash.
+type bundleSource struct {
+	Name   string
+	SHA256 string
+}
+
+// bundleBuilder collects certificates for the combined bundle, keeping the
+// first occurrence of each fingerprint in order.
+type bundleBuilder struct {
+	entries []*bundleEntry
+	index   map[string]*bundleEntry
+	sources []bundleSource
+}
+
+func newBundleBuilder() *bundleBuilder {
+	return &bundleBuilder{index: make(map[string]*bundleEntry)}
+}
+
+// add appends certs from source, merging certificates already in the bundle.
+// data is the raw source file, whose hash is recorded in the bundle header.
+func (b *bundleBuilder) add(certs []*x509.Certificate, source string, data []byte) {
+	b.sources = append(b.sources, bundleSource{Name: source, SHA256: fetcher.ComputeSHA256(data)})
+
+	for _, cert := range certs {
+		fingerprint := Fingerprint(cert)
+		if entry, ok := b.index[fingerprint]; ok {
+			if !slices.Contains(entry.Sources, source) {
+				entry.Sources = append(entry.Sources, source)
+			}
+			continue
+		}
+		entry := &bundleEntry{Cert: cert, Sources: []string{source}}
+		b.entries = append(b.entries, entry)
+		b.index[fingerprint] = entry
+	}
+}
+
+// encode writes the combined bundle as PEM with comment headers, in the style
+// of curl's cacert.pem. Every consumer skips text outside BEGIN/END markers,
+// so comments never contain a dash run that could be mistaken for one.
+func (b *bundleBuilder) encode(generated time.Time) []byte {
+	var buf bytes.Buffer
+
+	buf.WriteString("##\n")
+	buf.WriteString("## verifi combined CA bundle\n")
+	buf.WriteString("##\n")
+	buf.WriteString("## Generated automatically by verifi; manual edits are overwritten.\n")
+	fmt.Fprintf(&buf, "## Store schema version: %s\n", currentSchemaVersion)
+	fmt.Fprintf(&buf, "## Generated: %s\n", generated.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&buf, "## Certificates: %d\n", len(b.entries))
+	buf.WriteString("## Sources (SHA256):\n")
+	for _, source := range b.sources {
+		fmt.Fprintf(&buf, "##   %s %s\n", bundleComment(source.Name), source.SHA256)
+	}
+	buf.WriteString("##\n")
+
+	for _, entry := range b.entries {
+		buf.WriteString("\n")
+		fmt.Fprintf(&buf, "# Source: %s\n", bundleComment(strings.Join(entry.Sources, ", ")))
+		fmt.Fprintf(&buf, "# Subject: %s\n", bundleComment(entry.Cert.Subject.String()))
+		fmt.Fprintf(&buf, "# Fingerprint: %s\n", Fingerprint(entry.Cert))
+		fmt.Fprintf(&buf, "# Expires: %s\n", entry.Cert.NotAfter.UTC().Format(time.RFC3339))
+		buf.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: entry.Cert.Raw}))
+	}
+
+	return buf.Bytes()
+}
+
+// bundleComment makes s safe for a single comment line in a PEM bundle.
+func bundleComment(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "- -")
+	}
+	return s
+}
+
+// duplicates returns the certificates that came from more than one source.
+func (b *bundleBuilder) duplicates() []DuplicateCert {
+	var dups []DuplicateCert
+	for _, entry := range b.entries {
+		if len(entry.Sources) > 1 {
+			dups = append(dups, DuplicateCert{
+				Subject:     entry.Cert.Subject.String(),
+				Fingerprint: Fingerprint(entry.Cert),
+				Sources:     entry.Sources,
+			})
+		}
+	}
+	return dups
+}
+
+// FindDuplicates reports which of certs are already trusted by the store,
+// either through the Mozilla bundle or an existing user certificate. The user
+// certificate named exclude is ignored, so replacing an entry with itself is
+// not reported. Only certificates that are already present are returned.
+func (s *Store) FindDuplicates(certs []*x509.Certificate, exclude string) ([]DuplicateCert, error) {
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string][]string)
+
+	mozillaData, err := s.fs.ReadFile(s.mozillaBundlePath())
+	if err != nil {
+		return nil, &verifierrors.VerifiError{
+			Op:   "read mozilla bundle",
+			Path: s.mozillaBundlePath(),
+			Err:  err,
+		}
+	}
+	remaining := mozillaData
+	for {
+		block, rest := pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+		remaining = rest
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			fingerprint := Fingerprint(cert)
+			existing[fingerprint] = append(existing[fingerprint], AnchorSourceMozilla)
+		}
+	}
+
+	for _, info := range metadata.UserCerts {
+		if info.Name == exclude || info.Disabled || info.TrustLapsed(time.Now()) {
+			continue
+		}
+		fingerprints := []string{info.Fingerprint}
+		for _, c := range info.Certificates {
+			fingerprints = append(fingerprints, c.Fingerprint)
+		}
+		for _, fingerprint := range fingerprints {
+			if !slices.Contains(existing[fingerprint], "user:"+info.Name) {
+				existing[fingerprint] = append(existing[fingerprint], "user:"+info.Name)
+			}
+		}
+	}
+
+	var dups []DuplicateCert
+	for _, cert := range certs {
+		fingerprint := Fingerprint(cert)
+		if sources, ok := existing[fingerprint]; ok {
+			dups = append(dups, DuplicateCert{
+				Subject:     cert.Subject.String(),
+				Fingerprint: fingerprint,
+				Sources:     sources,
+			})
+		}
+	}
+
+	return dups, nil
+}
+
+// decodeBundleCerts parses every PEM block in data as a certificate. Unlike a
+// byte-for-byte copy, this tolerates CRLF line endings and text between blocks,
+// and it fails on anything that cannot be re-encoded cleanly: a non-certificate
+// block, an unparseable certificate, or a file with no certificates at all.
+func decodeBundleCerts(data []byte, path string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	remaining := data
+	for {
+		block, rest := pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+		remaining = rest
+
+		if block.Type != "CERTIFICATE" {
+			return nil, &verifierrors.VerifiError{
+				Op:   "decode bundle",
+				Path: path,
+				Err:  fmt.Errorf("%w: unexpected block type %s (expected CERTIFICATE)", verifierrors.ErrInvalidPEM, block.Type),
+			}
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, &verifierrors.VerifiError{
+				Op:   "decode bundle",
+				Path: path,
+				Err:  fmt.Errorf("invalid x509 certificate (block %d): %w", len(certs)+1, err),
+			}
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, &verifierrors.VerifiError{
+			Op:   "decode bundle",
+			Path: path,
+			Err:  fmt.Errorf("%w: no certificates found", verifierrors.ErrInvalidPEM),
+		}
+	}
+
+	return certs, nil
 }
 
 // userCertPath returns the full path for a user certificate by name
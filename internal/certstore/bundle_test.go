@@ -3,11 +3,14 @@ package certstore
 import (
 	"context"
 	"encoding/pem"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
 	"github.com/princespaghetti/verifi/internal/fetcher"
 )
 
@@ -243,11 +246,13 @@ func TestStore_readUserCerts(t *testing.T) {
 	userDir := filepath.Join(tmpDir, "certs", "user")
 	cert1 := generateTestCert(t, "Cert 1", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
 	cert2 := generateTestCert(t, "Cert 2", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	cert3 := generateTestCert(t, "Cert 3", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
 
-	if err := os.WriteFile(filepath.Join(userDir, "cert1.pem"), cert1, 0644); err != nil {
-		t.Fatalf("WriteFile() error = %v", err)
-	}
-	if err := os.WriteFile(filepath.Join(userDir, "cert2.pem"), cert2, 0644); err != nil {
+	writeUserCertFile(t, store, "cert1", cert1)
+	writeUserCertFile(t, store, "cert2", cert2)
+
+	// Files without a metadata entry are ignored
+	if err := os.WriteFile(filepath.Join(userDir, "stray.pem"), cert3, 0644); err != nil {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
@@ -291,3 +296,460 @@ func TestStore_AddCert_NotInitialized(t *testing.T) {
 		t.Error("AddCert() should fail when store is not initialized")
 	}
 }
+
+func TestStore_AddCert_Chain(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	leaf, ca := generateTestLeafCert(t, "proxy.corp.example")
+	root := generateTestCert(t, "Corp Root CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+
+	// Leading text and missing separators should not matter
+	chain := append([]byte("corp chain\n"), leaf...)
+	chain = append(chain, ca...)
+	chain = append(chain, root...)
+
+	certPath := filepath.Join(tmpDir, "corp-chain.pem")
+	if err := os.WriteFile(certPath, chain, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	t.Run("leaf in chain is refused by default", func(t *testing.T) {
+		err := store.AddCertWithOptions(ctx, certPath, "chain", AddCertOptions{})
+		if !errors.Is(err, verifierrors.ErrLeafCert) {
+			t.Fatalf("AddCertWithOptions() error = %v, want ErrLeafCert", err)
+		}
+		if _, err := store.GetCertInfo("chain"); !errors.Is(err, verifierrors.ErrCertNotFound) {
+			t.Errorf("GetCertInfo() error = %v, want ErrCertNotFound", err)
+		}
+	})
+
+	t.Run("all certificates", func(t *testing.T) {
+		if err := store.AddCertWithOptions(ctx, certPath, "chain", AddCertOptions{AllowLeaf: true}); err != nil {
+			t.Fatalf("AddCertWithOptions() error = %v", err)
+		}
+
+		info, err := store.GetCertInfo("chain")
+		if err != nil {
+			t.Fatalf("GetCertInfo() error = %v", err)
+		}
+		if len(info.Certificates) != 3 {
+			t.Fatalf("len(Certificates) = %d, want 3", len(info.Certificates))
+		}
+		if info.CertCount() != 3 {
+			t.Errorf("CertCount() = %d, want 3", info.CertCount())
+		}
+		// Earliest expiry is the leaf's
+		if !info.Expires.Equal(info.Certificates[0].Expires) {
+			t.Errorf("Expires = %v, want earliest expiry %v", info.Expires, info.Certificates[0].Expires)
+		}
+		wantRoles := []CertRole{RoleLeaf, RoleRoot, RoleRoot}
+		for i, c := range info.Certificates {
+			if c.Role != wantRoles[i] {
+				t.Errorf("Certificates[%d].Role = %q, want %q", i, c.Role, wantRoles[i])
+			}
+		}
+		if info.Role != RoleLeaf {
+			t.Errorf("Role = %q, want %q", info.Role, RoleLeaf)
+		}
+
+		stored, err := os.ReadFile(store.userCertPath("chain"))
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+		if fetcher.CountCertificates(stored) != 3 {
+			t.Errorf("stored file has %d certs, want 3", fetcher.CountCertificates(stored))
+		}
+		if strings.Contains(string(stored), "corp chain") {
+			t.Error("stored file should contain only re-encoded certificates")
+		}
+	})
+
+	t.Run("CA only", func(t *testing.T) {
+		if err := store.AddCertWithOptions(ctx, certPath, "chain-ca", AddCertOptions{CAOnly: true}); err != nil {
+			t.Fatalf("AddCertWithOptions() error = %v", err)
+		}
+
+		info, err := store.GetCertInfo("chain-ca")
+		if err != nil {
+			t.Fatalf("GetCertInfo() error = %v", err)
+		}
+		if len(info.Certificates) != 2 {
+			t.Fatalf("len(Certificates) = %d, want 2", len(info.Certificates))
+		}
+		for _, c := range info.Certificates {
+			if !c.IsCA {
+				t.Errorf("non-CA certificate %s was imported", c.Subject)
+			}
+		}
+	})
+
+	t.Run("CA only without CA certificates", func(t *testing.T) {
+		leafPath := filepath.Join(tmpDir, "leaf.pem")
+		if err := os.WriteFile(leafPath, leaf, 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		err := store.AddCertWithOptions(ctx, leafPath, "leaf", AddCertOptions{CAOnly: true})
+		if !errors.Is(err, verifierrors.ErrNoCACerts) {
+			t.Errorf("AddCertWithOptions() error = %v, want ErrNoCACerts", err)
+		}
+	})
+}
+
+func TestStore_AddCert_RefusesPrivateKey(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	certPEM := generateTestCert(t, "Corporate CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	certPath := filepath.Join(tmpDir, "with-key.pem")
+	if err := os.WriteFile(certPath, append(certPEM, []byte(nonCertPEM)...), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	err = store.AddCert(ctx, certPath, "with-key", false)
+	if !errors.Is(err, verifierrors.ErrPrivateKey) {
+		t.Fatalf("AddCert() error = %v, want ErrPrivateKey", err)
+	}
+
+	if _, err := os.Stat(store.userCertPath("with-key")); !os.IsNotExist(err) {
+		t.Errorf("user cert file should not exist after refusal, stat error = %v", err)
+	}
+
+	combined, err := os.ReadFile(store.CombinedBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if strings.Contains(string(combined), "PRIVATE KEY") {
+		t.Error("combined bundle must not contain private key material")
+	}
+}
+
+func TestStore_RebuildBundle_Canonicalizes(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	before, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	mozillaCount := before.CombinedBundle.CertCount
+
+	cert1 := generateTestCert(t, "CRLF CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	cert2 := generateTestCert(t, "Junk CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	cert3 := generateTestCert(t, "No Newline CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+
+	files := map[string][]byte{
+		"crlf":       []byte(strings.ReplaceAll(string(cert1), "\n", "\r\n")),
+		"junk":       append([]byte("exported from keychain\n"), cert2...),
+		"no-newline": []byte(strings.TrimRight(string(cert3), "\n")),
+	}
+	for name, data := range files {
+		writeUserCertFile(t, store, name, data)
+	}
+
+	if err := store.UpdateMetadata(ctx, func(md *Metadata) error {
+		return store.RebuildBundle(ctx, md)
+	}); err != nil {
+		t.Fatalf("RebuildBundle() error = %v", err)
+	}
+
+	combined, err := os.ReadFile(store.CombinedBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	if strings.Contains(string(combined), "\r") {
+		t.Error("combined bundle should not contain CR characters")
+	}
+	if strings.Contains(string(combined), "keychain") {
+		t.Error("combined bundle should not contain text from user files")
+	}
+	for _, line := range strings.Split(string(combined), "\n") {
+		if strings.Contains(line, "-----BEGIN") && !strings.HasPrefix(line, "-----BEGIN") {
+			t.Fatalf("BEGIN marker not at start of line: %q", line)
+		}
+	}
+
+	metadata, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	if metadata.CombinedBundle.CertCount != mozillaCount+3 {
+		t.Errorf("CertCount = %d, want %d", metadata.CombinedBundle.CertCount, mozillaCount+3)
+	}
+	if got := strings.Count(string(combined), "-----BEGIN CERTIFICATE-----"); got != metadata.CombinedBundle.CertCount {
+		t.Errorf("bundle has %d BEGIN markers, CertCount = %d", got, metadata.CombinedBundle.CertCount)
+	}
+}
+
+func TestStore_RebuildBundle_FailsOnUnparseableUserFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	original, err := os.ReadFile(store.CombinedBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	writeUserCertFile(t, store, "broken", []byte("not a certificate\n"))
+
+	err = store.UpdateMetadata(ctx, func(md *Metadata) error {
+		return store.RebuildBundle(ctx, md)
+	})
+	if err == nil {
+		t.Fatal("RebuildBundle() should fail when a user file cannot be parsed")
+	}
+	if !strings.Contains(err.Error(), "broken.pem") {
+		t.Errorf("error should name the broken file, got: %v", err)
+	}
+
+	current, err := os.ReadFile(store.CombinedBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(current) != string(original) {
+		t.Error("combined bundle should be left untouched when the rebuild fails")
+	}
+}
+
+func TestStore_RebuildBundle_Deduplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	before, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	mozillaCount := before.CombinedBundle.CertCount
+
+	// A root Mozilla already ships
+	mozillaData, err := os.ReadFile(store.mozillaBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	mozillaCerts, err := decodeBundleCerts(mozillaData, store.mozillaBundlePath())
+	if err != nil {
+		t.Fatalf("decodeBundleCerts() error = %v", err)
+	}
+	mozPath := filepath.Join(tmpDir, "moz-root.pem")
+	if err := os.WriteFile(mozPath, EncodeCertsPEM(mozillaCerts[:1]), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// The same corporate CA under two names
+	corpPEM := generateTestCert(t, "Corp CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	corpPath := filepath.Join(tmpDir, "corp.pem")
+	if err := os.WriteFile(corpPath, corpPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	corpCerts, _, err := ValidateCertChain(corpPEM, false)
+	if err != nil {
+		t.Fatalf("ValidateCertChain() error = %v", err)
+	}
+
+	// Nothing to report before the first add
+	dups, err := store.FindDuplicates(corpCerts, "")
+	if err != nil {
+		t.Fatalf("FindDuplicates() error = %v", err)
+	}
+	if len(dups) != 0 {
+		t.Errorf("FindDuplicates() = %v, want none", dups)
+	}
+
+	for name, path := range map[string]string{"moz-root": mozPath, "corp": corpPath} {
+		if err := store.AddCert(ctx, path, name, false); err != nil {
+			t.Fatalf("AddCert(%s) error = %v", name, err)
+		}
+	}
+
+	// FindDuplicates reports the existing user cert, but not the entry being replaced
+	dups, err = store.FindDuplicates(corpCerts, "corp-copy")
+	if err != nil {
+		t.Fatalf("FindDuplicates() error = %v", err)
+	}
+	if len(dups) != 1 || len(dups[0].Sources) != 1 || dups[0].Sources[0] != "user:corp" {
+		t.Errorf("FindDuplicates() = %+v, want one duplicate from user:corp", dups)
+	}
+	if dups, _ := store.FindDuplicates(corpCerts, "corp"); len(dups) != 0 {
+		t.Errorf("FindDuplicates() excluding corp = %+v, want none", dups)
+	}
+
+	dups, err = store.FindDuplicates(mozillaCerts[:1], "")
+	if err != nil {
+		t.Fatalf("FindDuplicates() error = %v", err)
+	}
+	if len(dups) != 1 || dups[0].Sources[0] != AnchorSourceMozilla {
+		t.Errorf("FindDuplicates() = %+v, want mozilla first", dups)
+	}
+
+	if err := store.AddCert(ctx, corpPath, "corp-copy", false); err != nil {
+		t.Fatalf("AddCert(corp-copy) error = %v", err)
+	}
+
+	metadata, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+
+	// Only the corporate CA is new
+	if metadata.CombinedBundle.CertCount != mozillaCount+1 {
+		t.Errorf("CertCount = %d, want %d", metadata.CombinedBundle.CertCount, mozillaCount+1)
+	}
+
+	combined, err := os.ReadFile(store.CombinedBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if got := fetcher.CountCertificates(combined); got != metadata.CombinedBundle.CertCount {
+		t.Errorf("bundle holds %d certificates, CertCount = %d", got, metadata.CombinedBundle.CertCount)
+	}
+
+	if len(metadata.CombinedBundle.Duplicates) != 2 {
+		t.Fatalf("Duplicates = %+v, want 2 entries", metadata.CombinedBundle.Duplicates)
+	}
+	for _, dup := range metadata.CombinedBundle.Duplicates {
+		switch dup.Fingerprint {
+		case Fingerprint(mozillaCerts[0]):
+			if strings.Join(dup.Sources, ",") != "mozilla,user:moz-root" {
+				t.Errorf("Mozilla duplicate sources = %v", dup.Sources)
+			}
+		case Fingerprint(corpCerts[0]):
+			if strings.Join(dup.Sources, ",") != "user:corp,user:corp-copy" { // user certs are read in metadata order
+				t.Errorf("corp duplicate sources = %v", dup.Sources)
+			}
+		default:
+			t.Errorf("unexpected duplicate %+v", dup)
+		}
+	}
+
+	// Sources still records that user certificates contributed
+	if !strings.Contains(strings.Join(metadata.CombinedBundle.Sources, ","), "user") {
+		t.Errorf("Sources = %v, want user included", metadata.CombinedBundle.Sources)
+	}
+}
+
+func TestStore_RebuildBundle_Annotated(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	// A subject containing a dash run must not produce a fake PEM marker
+	certPEM := generateTestCert(t, "Corp -----BEGIN CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	certPath := filepath.Join(tmpDir, "corp.pem")
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, certPath, "corp", false); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+
+	combined, err := os.ReadFile(store.CombinedBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	content := string(combined)
+
+	for _, want := range []string{
+		"## verifi combined CA bundle",
+		"## Store schema version: " + currentSchemaVersion,
+		"##   mozilla ",
+		"##   user:corp ",
+		"# Source: user:corp",
+		"# Source: mozilla",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("combined bundle missing %q", want)
+		}
+	}
+
+	corpCerts, _, err := ValidateCertChain(certPEM, false)
+	if err != nil {
+		t.Fatalf("ValidateCertChain() error = %v", err)
+	}
+	if !strings.Contains(content, "# Fingerprint: "+Fingerprint(corpCerts[0])) {
+		t.Error("combined bundle missing user cert fingerprint comment")
+	}
+
+	// Comments never contain dash runs, and all markers start a line
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "#") && strings.Contains(line, "--") {
+			t.Errorf("comment line contains a dash run: %q", line)
+		}
+	}
+
+	metadata, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	if got := fetcher.CountCertificates(combined); got != metadata.CombinedBundle.CertCount {
+		t.Errorf("parsed %d certificates, CertCount = %d", got, metadata.CombinedBundle.CertCount)
+	}
+	if got := strings.Count(content, "\n# Source: "); got != metadata.CombinedBundle.CertCount {
+		t.Errorf("found %d certificate headers, want %d", got, metadata.CombinedBundle.CertCount)
+	}
+}
+
+// writeUserCertFile writes data to certs/user/<name>.pem and registers a
+// minimal metadata entry for it, bypassing validation in AddCert.
+func writeUserCertFile(t *testing.T, store *Store, name string, data []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(store.userCertPath(name), data, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.UpdateMetadata(context.Background(), func(md *Metadata) error {
+		md.UserCerts = append(md.UserCerts, UserCertInfo{Name: name, Path: "user/" + name + ".pem"})
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateMetadata() error = %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package certstore
+
+import (
+	"sort"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// ExpiringCert describes a trusted certificate that expires within a window.
+// Source is "user:<name>" or "mozilla", as in the combined bundle.
+type ExpiringCert struct {
+	Source      string    `json:"source"`
+	Subject     string    `json:"subject"`
+	Fingerprint string    `json:"fingerprint"`
+	Expires     time.Time `json:"expires"`
+}
+
+// ExpiringCerts returns the certificates in the trust set that have expired
+// or expire before now+within, soonest first. Every certificate of a
+// multi-certificate user entry is checked; disabled entries and entries
+// whose temporary trust has lapsed are skipped since they are not trusted.
+// Mozilla roots are included when includeMozilla is true.
+func (s *Store) ExpiringCerts(now time.Time, within time.Duration, includeMozilla bool) ([]ExpiringCert, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "check expiry",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := now.Add(within)
+	var expiring []ExpiringCert
+
+	for _, info := range metadata.UserCerts {
+		if info.Disabled || info.TrustLapsed(now) {
+			continue
+		}
+
+		certs := info.Certificates
+		if len(certs) == 0 {
+			certs = []CertSummary{{Subject: info.Subject, Fingerprint: info.Fingerprint, Expires: info.Expires}}
+		}
+		for _, cert := range certs {
+			if cert.Expires.Before(deadline) {
+				expiring = append(expiring, ExpiringCert{
+					Source:      "user:" + info.Name,
+					Subject:     cert.Subject,
+					Fingerprint: cert.Fingerprint,
+					Expires:     cert.Expires,
+				})
+			}
+		}
+	}
+
+	if includeMozilla {
+		path := s.mozillaBundlePath()
+		data, err := s.fs.ReadFile(path)
+		if err != nil {
+			return nil, &verifierrors.VerifiError{
+				Op:   "read mozilla bundle",
+				Path: path,
+				Err:  err,
+			}
+		}
+		certs, err := decodeBundleCerts(data, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, cert := range certs {
+			if cert.NotAfter.Before(deadline) {
+				expiring = append(expiring, ExpiringCert{
+					Source:      "mozilla",
+					Subject:     cert.Subject.String(),
+					Fingerprint: Fingerprint(cert),
+					Expires:     cert.NotAfter,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].Expires.Before(expiring[j].Expires)
+	})
+
+	return expiring, nil
+}
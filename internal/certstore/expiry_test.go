@@ -0,0 +1,71 @@
+package certstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_ExpiringCerts(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	soonPEM := generateTestCert(t, "Soon CA", now.Add(-24*time.Hour), now.Add(10*24*time.Hour))
+	soonPath := filepath.Join(tmpDir, "soon.pem")
+	if err := os.WriteFile(soonPath, soonPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, soonPath, "soon", false); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+
+	expiring, err := store.ExpiringCerts(now, 30*24*time.Hour, false)
+	if err != nil {
+		t.Fatalf("ExpiringCerts() error = %v", err)
+	}
+	if len(expiring) != 1 || expiring[0].Source != "user:soon" || expiring[0].Subject != "CN=Soon CA" {
+		t.Errorf("ExpiringCerts(30d) = %+v, want only user:soon", expiring)
+	}
+
+	expiring, err = store.ExpiringCerts(now, 400*24*time.Hour, false)
+	if err != nil {
+		t.Fatalf("ExpiringCerts() error = %v", err)
+	}
+	if len(expiring) != 2 || expiring[0].Source != "user:soon" || expiring[1].Source != "user:corp" {
+		t.Errorf("ExpiringCerts(400d) = %+v, want soon then corp", expiring)
+	}
+
+	t.Run("disabled certificates are skipped", func(t *testing.T) {
+		if err := store.DisableCert(ctx, "soon"); err != nil {
+			t.Fatalf("DisableCert() error = %v", err)
+		}
+		defer func() { _ = store.EnableCert(ctx, "soon") }()
+
+		expiring, err := store.ExpiringCerts(now, 30*24*time.Hour, false)
+		if err != nil {
+			t.Fatalf("ExpiringCerts() error = %v", err)
+		}
+		if len(expiring) != 0 {
+			t.Errorf("ExpiringCerts() = %+v, want none", expiring)
+		}
+	})
+
+	t.Run("mozilla roots", func(t *testing.T) {
+		expiring, err := store.ExpiringCerts(now, 100*365*24*time.Hour, true)
+		if err != nil {
+			t.Fatalf("ExpiringCerts() error = %v", err)
+		}
+		mozilla := 0
+		for _, cert := range expiring {
+			if cert.Source == "mozilla" {
+				mozilla++
+			}
+		}
+		if mozilla == 0 {
+			t.Error("ExpiringCerts() should include Mozilla roots within a 100 year window")
+		}
+	})
+}
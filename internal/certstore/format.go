@@ -0,0 +1,221 @@
+package certstore
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// CertFormat identifies the encoding a certificate file was imported from.
+type CertFormat string
+
+// Supported certificate input formats.
+const (
+	FormatPEM       CertFormat = "pem"        // PEM CERTIFICATE blocks
+	FormatDER       CertFormat = "der"        // Raw DER (.cer, .crt, .der)
+	FormatBase64DER CertFormat = "base64-der" // Base64 DER without PEM headers
+	FormatPKCS7     CertFormat = "pkcs7"      // PKCS#7 certificate bag (.p7b, .p7c)
+	FormatPKCS12    CertFormat = "pkcs12"     // PKCS#12 truststore or keystore (.p12, .pfx)
+)
+
+// oidSignedData is the PKCS#7 signedData content type.
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// pkcs7ContentInfo is the outer PKCS#7 ContentInfo structure.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// pkcs7SignedData is the PKCS#7 SignedData structure. Only the certificates
+// are used; a .p7b certificate bag is a degenerate SignedData with no signers.
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+// ConvertToPEM sniffs the format of certificate data and converts it to PEM.
+// PEM input is returned unchanged (PKCS#7 PEM blocks are expanded) so that it
+// can still be validated block by block. DER, header-less base64 DER, PKCS#7
+// certificate bags and PKCS#12 files are converted to CERTIFICATE blocks.
+// The password is only used for PKCS#12 input; any private keys are discarded.
+func ConvertToPEM(data []byte, password string) ([]byte, CertFormat, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, "", &verifierrors.VerifiError{
+			Op:  "detect certificate format",
+			Err: verifierrors.ErrInvalidPEM,
+		}
+	}
+
+	// PEM input
+	if bytes.Contains(data, []byte("-----BEGIN")) {
+		return convertPEM(data)
+	}
+
+	// Binary DER-based input
+	if certs, format, err := decodeDER(data, password); format != "" {
+		if err != nil {
+			return nil, format, err
+		}
+		return EncodeCertsPEM(certs), format, nil
+	}
+
+	// Base64 without PEM headers
+	if der, err := base64.StdEncoding.DecodeString(string(stripWhitespace(data))); err == nil {
+		certs, format, err := decodeDER(der, password)
+		if format != "" {
+			if err != nil {
+				return nil, format, err
+			}
+			if format == FormatDER {
+				format = FormatBase64DER
+			}
+			return EncodeCertsPEM(certs), format, nil
+		}
+	}
+
+	return nil, "", &verifierrors.VerifiError{
+		Op:  "detect certificate format",
+		Err: fmt.Errorf("%w: input is not PEM, DER, PKCS#7 or PKCS#12", verifierrors.ErrInvalidPEM),
+	}
+}
+
+// convertPEM expands any PKCS#7 blocks in PEM input into CERTIFICATE blocks.
+// Other blocks are passed through untouched for validation to reject.
+func convertPEM(data []byte) ([]byte, CertFormat, error) {
+	if !bytes.Contains(data, []byte("-----BEGIN PKCS7-----")) {
+		return data, FormatPEM, nil
+	}
+
+	var out []byte
+	remaining := data
+	for {
+		block, rest := pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+		remaining = rest
+
+		if block.Type != "PKCS7" {
+			out = append(out, pem.EncodeToMemory(block)...)
+			continue
+		}
+
+		certs, err := parsePKCS7(block.Bytes)
+		if err != nil {
+			return nil, FormatPKCS7, &verifierrors.VerifiError{
+				Op:  "decode PKCS#7",
+				Err: err,
+			}
+		}
+		out = append(out, EncodeCertsPEM(certs)...)
+	}
+
+	return out, FormatPKCS7, nil
+}
+
+// decodeDER tries each DER-based format in turn. It returns an empty format
+// if data is not recognized, or the detected format with an error if the data
+// was recognized but could not be decoded (e.g. a wrong PKCS#12 password).
+func decodeDER(data []byte, password string) ([]*x509.Certificate, CertFormat, error) {
+	// All supported binary formats start with an ASN.1 SEQUENCE
+	if len(data) == 0 || data[0] != 0x30 {
+		return nil, "", nil
+	}
+
+	if certs, err := x509.ParseCertificates(data); err == nil && len(certs) > 0 {
+		return certs, FormatDER, nil
+	}
+
+	if certs, err := parsePKCS7(data); err == nil {
+		return certs, FormatPKCS7, nil
+	}
+
+	certs, err := parsePKCS12(data, password)
+	if err == nil {
+		return certs, FormatPKCS12, nil
+	}
+	if errors.Is(err, verifierrors.ErrIncorrectPassword) {
+		return nil, FormatPKCS12, &verifierrors.VerifiError{
+			Op:  "decode PKCS#12",
+			Err: err,
+		}
+	}
+
+	return nil, "", nil
+}
+
+// parsePKCS7 extracts the certificates from a DER-encoded PKCS#7 SignedData.
+func parsePKCS7(der []byte) ([]*x509.Certificate, error) {
+	var ci pkcs7ContentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, fmt.Errorf("parse content info: %w", err)
+	} else if len(rest) > 0 {
+		return nil, fmt.Errorf("trailing data after content info")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unsupported PKCS#7 content type %s", ci.ContentType)
+	}
+
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("parse signed data: %w", err)
+	}
+	if len(sd.Certificates.Bytes) == 0 {
+		return nil, fmt.Errorf("PKCS#7 data contains no certificates")
+	}
+
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificates: %w", err)
+	}
+	return certs, nil
+}
+
+// parsePKCS12 extracts the certificates from a PKCS#12 file. Java-style
+// truststores are tried first; keystores fall back to their certificate chain
+// with the private key discarded.
+func parsePKCS12(der []byte, password string) ([]*x509.Certificate, error) {
+	certs, err := pkcs12.DecodeTrustStore(der, password)
+	if err == nil {
+		if len(certs) == 0 {
+			return nil, fmt.Errorf("PKCS#12 truststore contains no certificates")
+		}
+		return certs, nil
+	}
+	if errors.Is(err, pkcs12.ErrIncorrectPassword) {
+		return nil, fmt.Errorf("%w: %v", verifierrors.ErrIncorrectPassword, err)
+	}
+
+	_, cert, caCerts, chainErr := pkcs12.DecodeChain(der, password)
+	if chainErr != nil {
+		if errors.Is(chainErr, pkcs12.ErrIncorrectPassword) {
+			return nil, fmt.Errorf("%w: %v", verifierrors.ErrIncorrectPassword, chainErr)
+		}
+		return nil, err
+	}
+	return append([]*x509.Certificate{cert}, caCerts...), nil
+}
+
+// stripWhitespace removes all ASCII whitespace from data.
+func stripWhitespace(data []byte) []byte {
+	return bytes.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, data)
+}
@@ -0,0 +1,197 @@
+package certstore
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// derOf returns the DER bytes of the first block in certPEM.
+func derOf(t *testing.T, certPEM []byte) []byte {
+	t.Helper()
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("failed to decode test certificate")
+	}
+	return block.Bytes
+}
+
+// encodeTestPKCS7 builds a degenerate PKCS#7 SignedData holding the given DER certificates.
+func encodeTestPKCS7(t *testing.T, ders ...[]byte) []byte {
+	t.Helper()
+
+	var certBytes []byte
+	for _, der := range ders {
+		certBytes = append(certBytes, der...)
+	}
+
+	dataOID, err := asn1.Marshal(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1})
+	if err != nil {
+		t.Fatalf("marshal data OID: %v", err)
+	}
+
+	sd := pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+		ContentInfo:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSequence, IsCompound: true, Bytes: dataOID},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certBytes},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+	}
+	sdBytes, err := asn1.Marshal(sd)
+	if err != nil {
+		t.Fatalf("marshal signed data: %v", err)
+	}
+
+	ci, err := asn1.Marshal(struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"explicit,tag:0"`
+	}{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sdBytes},
+	})
+	if err != nil {
+		t.Fatalf("marshal content info: %v", err)
+	}
+	return ci
+}
+
+func TestConvertToPEM(t *testing.T) {
+	rootPEM := generateTestCert(t, "Format Root", time.Now().Add(-time.Hour), time.Now().Add(365*24*time.Hour))
+	otherPEM := generateTestCert(t, "Format Other", time.Now().Add(-time.Hour), time.Now().Add(365*24*time.Hour))
+	rootDER := derOf(t, rootPEM)
+	otherDER := derOf(t, otherPEM)
+
+	rootCert, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	otherCert, err := x509.ParseCertificate(otherDER)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+
+	p7 := encodeTestPKCS7(t, rootDER, otherDER)
+
+	trustStore, err := pkcs12.Modern2023.EncodeTrustStore([]*x509.Certificate{rootCert, otherCert}, "s3cret")
+	if err != nil {
+		t.Fatalf("EncodeTrustStore() error = %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	leafPEM, _ := generateTestLeafCert(t, "keystore.example")
+	leafCert, err := x509.ParseCertificate(derOf(t, leafPEM))
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	// The key does not match the leaf, but the encoder does not check that
+	keyStore, err := pkcs12.Modern2023.Encode(key, leafCert, []*x509.Certificate{rootCert}, "s3cret")
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		password   string
+		wantFormat CertFormat
+		wantCerts  int
+		wantErr    error
+	}{
+		{name: "PEM", data: rootPEM, wantFormat: FormatPEM, wantCerts: 1},
+		{name: "DER", data: rootDER, wantFormat: FormatDER, wantCerts: 1},
+		{name: "base64 DER", data: []byte(base64.StdEncoding.EncodeToString(rootDER) + "\n"), wantFormat: FormatBase64DER, wantCerts: 1},
+		{name: "PKCS#7 DER", data: p7, wantFormat: FormatPKCS7, wantCerts: 2},
+		{name: "PKCS#7 PEM", data: pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: p7}), wantFormat: FormatPKCS7, wantCerts: 2},
+		{name: "PKCS#7 base64", data: []byte(base64.StdEncoding.EncodeToString(p7)), wantFormat: FormatPKCS7, wantCerts: 2},
+		{name: "PKCS#12 truststore", data: trustStore, password: "s3cret", wantFormat: FormatPKCS12, wantCerts: 2},
+		{name: "PKCS#12 keystore", data: keyStore, password: "s3cret", wantFormat: FormatPKCS12, wantCerts: 2},
+		{name: "PKCS#12 wrong password", data: trustStore, password: "nope", wantFormat: FormatPKCS12, wantErr: verifierrors.ErrIncorrectPassword},
+		{name: "garbage", data: []byte("definitely not a certificate"), wantErr: verifierrors.ErrInvalidPEM},
+		{name: "empty", data: []byte("  \n"), wantErr: verifierrors.ErrInvalidPEM},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, format, err := ConvertToPEM(tt.data, tt.password)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ConvertToPEM() error = %v, want %v", err, tt.wantErr)
+				}
+				if format != tt.wantFormat {
+					t.Errorf("format = %q, want %q", format, tt.wantFormat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConvertToPEM() error = %v", err)
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+
+			certs, _, err := ValidateCertChain(out, false)
+			if err != nil {
+				t.Fatalf("ValidateCertChain() on converted output error = %v", err)
+			}
+			if len(certs) != tt.wantCerts {
+				t.Errorf("converted %d certs, want %d", len(certs), tt.wantCerts)
+			}
+		})
+	}
+}
+
+func TestStore_AddCert_DERRecordsFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	certPEM := generateTestCert(t, "Windows Root", time.Now().Add(-time.Hour), time.Now().Add(365*24*time.Hour))
+	certPath := filepath.Join(tmpDir, "root.cer")
+	if err := os.WriteFile(certPath, derOf(t, certPEM), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := store.AddCert(ctx, certPath, "windows", false); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+
+	info, err := store.GetCertInfo("windows")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+	if info.SourceFormat != FormatDER {
+		t.Errorf("SourceFormat = %q, want %q", info.SourceFormat, FormatDER)
+	}
+
+	stored, err := os.ReadFile(store.userCertPath("windows"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if block, _ := pem.Decode(stored); block == nil || block.Type != "CERTIFICATE" {
+		t.Error("stored certificate should be PEM encoded")
+	}
+}
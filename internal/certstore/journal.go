@@ -0,0 +1,398 @@
+package certstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+	"github.com/princespaghetti/verifi/internal/fetcher"
+)
+
+// Store operations change up to three artifacts: certificate files, the
+// metadata and the combined bundle. Each operation runs as a transaction
+// under the store lock. Before a file is replaced or removed, the journal
+// records it and the original is moved aside to a backup. Writing the
+// metadata commits the transaction; if it is never written, the journal is
+// used to put every file back.
+//
+// The journal also records the SHA256 of the metadata about to be written,
+// which tells a later recovery whether the crash happened before or after
+// the commit: after it, only the backups are left to delete (roll forward);
+// before it, the files are restored (roll back).
+
+// journal is the on-disk record of a transaction in progress.
+type journal struct {
+	ID      string        `json:"id"`
+	Op      string        `json:"op"`
+	Started time.Time     `json:"started"`
+	Files   []journalFile `json:"files,omitempty"`
+	// Bundle is the SHA256 of the combined bundle when the transaction
+	// started. The bundle is rebuilt rather than backed up, so a rollback
+	// rebuilds it from the restored metadata if it changed.
+	Bundle string `json:"bundle_sha256,omitempty"`
+	// Metadata is the SHA256 of the metadata being committed, set just
+	// before it is written.
+	Metadata string `json:"metadata_sha256,omitempty"`
+}
+
+// journalFile is a file changed by a transaction. Backup holds the original
+// contents; it is empty if the file did not exist.
+type journalFile struct {
+	Path   string `json:"path"`
+	Backup string `json:"backup,omitempty"`
+}
+
+// RecoveryResult describes a transaction completed or undone by Recover.
+type RecoveryResult struct {
+	Op      string    `json:"op"`
+	Started time.Time `json:"started"`
+	// RolledBack is true if the transaction was undone, false if it had
+	// committed and was completed.
+	RolledBack bool `json:"rolled_back"`
+	// Files is the number of files restored or cleaned up.
+	Files int `json:"files"`
+}
+
+// storeTx is a store transaction. File changes must go through its methods
+// so they can be undone; md is the metadata committed when the transaction
+// function returns nil.
+type storeTx struct {
+	s       *Store
+	j       *journal
+	md      *Metadata
+	touched map[string]bool
+}
+
+// journalPath returns the path to the transaction journal.
+func (s *Store) journalPath() string {
+	return filepath.Join(s.basePath, "journal.json")
+}
+
+// lockStore acquires the store lock. It keeps the metadata lock file, which
+// older versions already take for every metadata update.
+func (s *Store) lockStore(ctx context.Context) (*FileLock, error) {
+	lock := NewFileLock(s.metadataPath())
+	if err := lock.Lock(ctx); err != nil {
+		return nil, fmt.Errorf("failed to lock store: %w", err)
+	}
+	return lock, nil
+}
+
+// update runs fn as a transaction named op. It takes the store lock,
+// recovers an interrupted transaction, reads the metadata and runs fn. If fn
+// succeeds, the metadata is written and the transaction committed; if not,
+// every file changed through tx is restored and fn's error is returned.
+func (s *Store) update(ctx context.Context, op string, fn func(tx *storeTx) error) error {
+	lock, err := s.lockStore(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	if _, err := s.recoverLocked(ctx); err != nil {
+		return err
+	}
+
+	// Read current metadata
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return err
+	}
+
+	// Refuse before fn runs, so no files are changed for metadata we cannot write
+	if metadata.IsNewerSchema() {
+		return &verifierrors.VerifiError{
+			Op:   op,
+			Path: s.metadataPath(),
+			Err:  fmt.Errorf("%w: schema %s, this verifi supports up to %s", verifierrors.ErrMetadataTooNew, metadata.Version, currentSchemaVersion),
+		}
+	}
+
+	tx := &storeTx{
+		s:       s,
+		j:       &journal{ID: strconv.FormatInt(time.Now().UnixNano(), 36), Op: op, Started: time.Now(), Bundle: s.bundleSHA256()},
+		md:      metadata,
+		touched: make(map[string]bool),
+	}
+	if err := s.writeJournal(tx.j); err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return withRollback(err, s.rollback(ctx, tx.j))
+	}
+
+	data, err := s.encodeMetadata(metadata)
+	if err != nil {
+		return withRollback(err, s.rollback(ctx, tx.j))
+	}
+
+	// Record what is being committed, then commit
+	tx.j.Metadata = fetcher.ComputeSHA256(data)
+	if err := s.writeJournal(tx.j); err != nil {
+		return withRollback(err, s.rollback(ctx, tx.j))
+	}
+	if err := s.writeMetadataData(data); err != nil {
+		return withRollback(err, s.rollback(ctx, tx.j))
+	}
+
+	s.finish(tx.j)
+	return nil
+}
+
+// withRollback returns err, noting rbErr if the rollback failed too.
+func withRollback(err, rbErr error) error {
+	if rbErr == nil {
+		return err
+	}
+	return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+}
+
+// writeFile replaces the file at path with data.
+func (tx *storeTx) writeFile(path string, data []byte) error {
+	if err := tx.record(path); err != nil {
+		return err
+	}
+	return tx.s.writeFileAtomic(path, data)
+}
+
+// remove removes the file at path. A missing file is not an error.
+func (tx *storeTx) remove(path string) error {
+	if err := tx.record(path); err != nil {
+		return err
+	}
+	// record moved an existing file aside; this removes one written by tx
+	if err := tx.s.fs.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &verifierrors.VerifiError{
+			Op:   "remove file",
+			Path: path,
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+// rename moves the file at oldPath to newPath.
+func (tx *storeTx) rename(oldPath, newPath string) error {
+	data, err := tx.s.fs.ReadFile(oldPath)
+	if err != nil {
+		return &verifierrors.VerifiError{
+			Op:   "read file",
+			Path: oldPath,
+			Err:  err,
+		}
+	}
+	if err := tx.writeFile(newPath, data); err != nil {
+		return err
+	}
+	return tx.remove(oldPath)
+}
+
+// record journals path the first time tx changes it, then moves the
+// existing file, if any, to its backup.
+func (tx *storeTx) record(path string) error {
+	if tx.touched[path] {
+		return nil
+	}
+
+	entry := journalFile{Path: path}
+	if _, err := tx.s.fs.Stat(path); err == nil {
+		entry.Backup = fmt.Sprintf("%s.%s.bak", path, tx.j.ID)
+	}
+
+	tx.j.Files = append(tx.j.Files, entry)
+	if err := tx.s.writeJournal(tx.j); err != nil {
+		tx.j.Files = tx.j.Files[:len(tx.j.Files)-1]
+		return err
+	}
+	tx.touched[path] = true
+
+	if entry.Backup != "" {
+		if err := tx.s.fs.Rename(path, entry.Backup); err != nil {
+			return &verifierrors.VerifiError{
+				Op:   "back up file",
+				Path: path,
+				Err:  err,
+			}
+		}
+	}
+	return nil
+}
+
+// Recover completes or undoes a transaction interrupted by a crash, and
+// returns what it did, or nil if there was nothing to recover.
+func (s *Store) Recover(ctx context.Context) (*RecoveryResult, error) {
+	// Avoid taking the lock in the common case
+	if _, err := s.fs.Stat(s.journalPath()); err != nil {
+		return nil, nil
+	}
+
+	lock, err := s.lockStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	return s.recoverLocked(ctx)
+}
+
+// recoverLocked is Recover for callers holding the store lock.
+func (s *Store) recoverLocked(ctx context.Context) (*RecoveryResult, error) {
+	j, err := s.readJournal()
+	if err != nil || j == nil {
+		return nil, err
+	}
+
+	result := &RecoveryResult{Op: j.Op, Started: j.Started, Files: len(j.Files)}
+
+	if j.Metadata != "" {
+		if data, err := s.fs.ReadFile(s.metadataPath()); err == nil && fetcher.ComputeSHA256(data) == j.Metadata {
+			s.finish(j)
+			return result, nil
+		}
+	}
+
+	result.RolledBack = true
+	if err := s.rollback(ctx, j); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rollback restores every file recorded in j, newest first, makes sure the
+// combined bundle matches the metadata on disk and removes the journal.
+func (s *Store) rollback(ctx context.Context, j *journal) error {
+	var errs []error
+	for i := len(j.Files) - 1; i >= 0; i-- {
+		file := j.Files[i]
+		if file.Backup == "" {
+			if err := s.fs.Remove(file.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if _, err := s.fs.Stat(file.Backup); err != nil {
+			// Not moved aside yet, or already restored
+			continue
+		}
+		if err := s.fs.Rename(file.Backup, file.Path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		// Keep the journal so the next command can try again
+		return &verifierrors.VerifiError{
+			Op:   "roll back " + j.Op,
+			Path: s.journalPath(),
+			Err:  errors.Join(errs...),
+		}
+	}
+
+	if s.bundleSHA256() != j.Bundle {
+		if err := s.rebuildFromDisk(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := s.fs.Remove(s.journalPath()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &verifierrors.VerifiError{
+			Op:   "remove journal",
+			Path: s.journalPath(),
+			Err:  err,
+		}
+	}
+	return nil
+}
+
+// rebuildFromDisk rebuilds the combined bundle from the metadata on disk.
+func (s *Store) rebuildFromDisk(ctx context.Context) error {
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return err
+	}
+	if err := s.RebuildBundle(ctx, metadata); err != nil {
+		return err
+	}
+	return s.writeMetadata(metadata)
+}
+
+// bundleSHA256 returns the SHA256 of the combined bundle, or "" if it
+// cannot be read.
+func (s *Store) bundleSHA256() string {
+	data, err := s.fs.ReadFile(s.CombinedBundlePath())
+	if err != nil {
+		return ""
+	}
+	return fetcher.ComputeSHA256(data)
+}
+
+// finish deletes the backups of a committed transaction and its journal.
+// Anything left behind is cleaned up by the next recovery.
+func (s *Store) finish(j *journal) {
+	for _, file := range j.Files {
+		if file.Backup != "" {
+			_ = s.fs.Remove(file.Backup) // Ignore error - retried by recovery
+		}
+	}
+	_ = s.fs.Remove(s.journalPath()) // Ignore error - retried by recovery
+}
+
+// readJournal reads the journal, returning nil if there is none.
+func (s *Store) readJournal() (*journal, error) {
+	data, err := s.fs.ReadFile(s.journalPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, &verifierrors.VerifiError{
+			Op:   "read journal",
+			Path: s.journalPath(),
+			Err:  err,
+		}
+	}
+
+	var j journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, &verifierrors.VerifiError{
+			Op:   "parse journal",
+			Path: s.journalPath(),
+			Err:  err,
+		}
+	}
+	return &j, nil
+}
+
+// writeJournal atomically replaces the journal with j.
+func (s *Store) writeJournal(j *journal) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return &verifierrors.VerifiError{
+			Op:  "marshal journal",
+			Err: err,
+		}
+	}
+
+	tempPath := s.journalPath() + ".tmp"
+	if err := s.fs.WriteFile(tempPath, data, 0644); err != nil {
+		return &verifierrors.VerifiError{
+			Op:   "write journal",
+			Path: tempPath,
+			Err:  err,
+		}
+	}
+	if err := s.fs.Rename(tempPath, s.journalPath()); err != nil {
+		_ = s.fs.Remove(tempPath)
+		return &verifierrors.VerifiError{
+			Op:   "write journal",
+			Path: s.journalPath(),
+			Err:  err,
+		}
+	}
+	return nil
+}
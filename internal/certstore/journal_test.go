@@ -0,0 +1,217 @@
+package certstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/princespaghetti/verifi/internal/fetcher"
+)
+
+// beginCrashingTx starts a transaction outside update, so a test can stop
+// at any point to simulate a crash.
+func beginCrashingTx(t *testing.T, store *Store) *storeTx {
+	t.Helper()
+	md, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	tx := &storeTx{
+		s:       store,
+		j:       &journal{ID: "test", Op: "remove certificate", Started: time.Now(), Bundle: store.bundleSHA256()},
+		md:      md,
+		touched: make(map[string]bool),
+	}
+	if err := store.writeJournal(tx.j); err != nil {
+		t.Fatalf("writeJournal() error = %v", err)
+	}
+	return tx
+}
+
+// removeCorpInTx removes the "corp" certificate within tx, up to but not
+// including the commit.
+func removeCorpInTx(t *testing.T, tx *storeTx) {
+	t.Helper()
+	if err := tx.remove(tx.s.userCertPath("corp")); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	tx.md.UserCerts = nil
+	if err := tx.s.RebuildBundle(context.Background(), tx.md); err != nil {
+		t.Fatalf("RebuildBundle() error = %v", err)
+	}
+}
+
+func assertNoJournal(t *testing.T, store *Store) {
+	t.Helper()
+	if _, err := os.Stat(store.journalPath()); !os.IsNotExist(err) {
+		t.Errorf("journal should be removed, stat error = %v", err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(store.BasePath(), "certs", "user", "*.bak"))
+	if len(backups) > 0 {
+		t.Errorf("backups left behind: %v", backups)
+	}
+}
+
+func TestStore_Recover_RollsBackUncommitted(t *testing.T) {
+	store, _ := newRotationTestStore(t)
+	ctx := context.Background()
+
+	tx := beginCrashingTx(t, store)
+	removeCorpInTx(t, tx)
+	// Crash before the metadata is written
+
+	if combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Fatal("test setup: the bundle should have been rebuilt without the certificate")
+	}
+
+	result, err := store.Recover(ctx)
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if result == nil || !result.RolledBack || result.Op != "remove certificate" {
+		t.Errorf("Recover() = %+v, want a rollback of 'remove certificate'", result)
+	}
+
+	if _, err := os.Stat(store.userCertPath("corp")); err != nil {
+		t.Errorf("certificate file should be restored: %v", err)
+	}
+	if _, err := store.GetCertInfo("corp"); err != nil {
+		t.Errorf("GetCertInfo() error = %v", err)
+	}
+	if !combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Error("combined bundle should be rebuilt to match the metadata")
+	}
+	assertNoJournal(t, store)
+
+	// Nothing left to recover
+	if result, err := store.Recover(ctx); err != nil || result != nil {
+		t.Errorf("second Recover() = %+v, %v, want nil", result, err)
+	}
+}
+
+func TestStore_Recover_RollsForwardCommitted(t *testing.T) {
+	store, _ := newRotationTestStore(t)
+	ctx := context.Background()
+
+	tx := beginCrashingTx(t, store)
+	removeCorpInTx(t, tx)
+
+	// Commit, then crash before the backups and journal are cleaned up
+	data, err := store.encodeMetadata(tx.md)
+	if err != nil {
+		t.Fatalf("encodeMetadata() error = %v", err)
+	}
+	tx.j.Metadata = fetcher.ComputeSHA256(data)
+	if err := store.writeJournal(tx.j); err != nil {
+		t.Fatalf("writeJournal() error = %v", err)
+	}
+	if err := store.writeMetadataData(data); err != nil {
+		t.Fatalf("writeMetadataData() error = %v", err)
+	}
+
+	result, err := store.Recover(ctx)
+	if err != nil {
+		t.Fatalf("Recover() error = %v", err)
+	}
+	if result == nil || result.RolledBack {
+		t.Errorf("Recover() = %+v, want the transaction completed", result)
+	}
+
+	if _, err := os.Stat(store.userCertPath("corp")); !os.IsNotExist(err) {
+		t.Errorf("certificate file should stay removed, stat error = %v", err)
+	}
+	if _, err := store.GetCertInfo("corp"); err == nil {
+		t.Error("certificate should stay removed from metadata")
+	}
+	if combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Error("combined bundle should not contain the removed certificate")
+	}
+	assertNoJournal(t, store)
+}
+
+func TestStore_Update_RecoversBeforeRunning(t *testing.T) {
+	store, _ := newRotationTestStore(t)
+	ctx := context.Background()
+
+	tx := beginCrashingTx(t, store)
+	removeCorpInTx(t, tx)
+
+	// The next transaction sees the store as it was before the crash
+	err := store.UpdateMetadata(ctx, func(md *Metadata) error {
+		if len(md.UserCerts) != 1 {
+			t.Errorf("len(UserCerts) = %d, want 1 after recovery", len(md.UserCerts))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateMetadata() error = %v", err)
+	}
+	if _, err := os.Stat(store.userCertPath("corp")); err != nil {
+		t.Errorf("certificate file should be restored: %v", err)
+	}
+	assertNoJournal(t, store)
+}
+
+func TestStore_Update_RollsBackOnError(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	before, err := os.ReadFile(store.userCertPath("corp"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	newPEM := generateTestCert(t, "Corp Root 2026", time.Now().Add(-time.Hour), time.Now().Add(365*24*time.Hour))
+	extraPath := filepath.Join(tmpDir, "certs", "user", "extra.pem")
+
+	errBoom := errors.New("boom")
+	err = store.update(ctx, "test", func(tx *storeTx) error {
+		if err := tx.writeFile(store.userCertPath("corp"), newPEM); err != nil {
+			return err
+		}
+		if err := tx.writeFile(extraPath, newPEM); err != nil {
+			return err
+		}
+		tx.md.UserCerts = nil
+		if err := store.RebuildBundle(ctx, tx.md); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("update() error = %v, want %v", err, errBoom)
+	}
+
+	after, err := os.ReadFile(store.userCertPath("corp"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(after) != string(before) {
+		t.Error("replaced file should be restored")
+	}
+	if _, err := os.Stat(extraPath); !os.IsNotExist(err) {
+		t.Errorf("file created by the failed transaction should be removed, stat error = %v", err)
+	}
+	if !combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Error("combined bundle should be rebuilt to match the metadata")
+	}
+	assertNoJournal(t, store)
+}
+
+func TestStore_RemoveCert_SingleTransaction(t *testing.T) {
+	store, _ := newRotationTestStore(t)
+	ctx := context.Background()
+
+	if err := store.RemoveCert(ctx, "corp"); err != nil {
+		t.Fatalf("RemoveCert() error = %v", err)
+	}
+	if _, err := os.Stat(store.userCertPath("corp")); !os.IsNotExist(err) {
+		t.Errorf("certificate file should be removed, stat error = %v", err)
+	}
+	if combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Error("combined bundle should not contain the removed certificate")
+	}
+	assertNoJournal(t, store)
+}
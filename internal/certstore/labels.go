@@ -0,0 +1,107 @@
+package certstore
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// CertLabelEdit describes changes to the labels (tags, note and owner) of a
+// user certificate. Nil fields are left unchanged.
+type CertLabelEdit struct {
+	// Tags replaces all tags when non-nil; an empty slice clears them.
+	Tags []string
+	// AddTags and RemoveTags are applied after Tags.
+	AddTags    []string
+	RemoveTags []string
+	// Note and Owner replace the current values; an empty string clears them.
+	Note  *string
+	Owner *string
+}
+
+// HasTags reports whether the entry carries every one of tags.
+func (c UserCertInfo) HasTags(tags []string) bool {
+	for _, tag := range normalizeTags(tags) {
+		if !slices.Contains(c.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// EditCertLabels changes the labels of a user certificate. Labels only live
+// in metadata, so the combined bundle is not rebuilt.
+// Returns ErrCertNotFound if the certificate doesn't exist.
+func (s *Store) EditCertLabels(ctx context.Context, name string, edit CertLabelEdit) (*UserCertInfo, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "edit certificate",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	// Check context
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var edited UserCertInfo
+	err := s.update(ctx, "edit certificate", func(tx *storeTx) error {
+		for i := range tx.md.UserCerts {
+			info := &tx.md.UserCerts[i]
+			if info.Name != name {
+				continue
+			}
+
+			tags := info.Tags
+			if edit.Tags != nil {
+				tags = edit.Tags
+			}
+			tags = append(slices.Clone(tags), edit.AddTags...)
+			remove := normalizeTags(edit.RemoveTags)
+			tags = slices.DeleteFunc(normalizeTags(tags), func(tag string) bool {
+				return slices.Contains(remove, tag)
+			})
+			info.Tags = tags
+
+			if edit.Note != nil {
+				info.Note = strings.TrimSpace(*edit.Note)
+			}
+			if edit.Owner != nil {
+				info.Owner = strings.TrimSpace(*edit.Owner)
+			}
+
+			edited = *info
+			return nil
+		}
+
+		return &verifierrors.VerifiError{
+			Op:   "edit certificate",
+			Path: name,
+			Err:  verifierrors.ErrCertNotFound,
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &edited, nil
+}
+
+// normalizeTags trims tags and drops empty and repeated ones, keeping the
+// original order. It returns nil if no tags remain.
+func normalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(out, tag) {
+			continue
+		}
+		out = append(out, tag)
+	}
+	return out
+}
@@ -0,0 +1,128 @@
+package certstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+func TestStore_CertLabels(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	certPEM := generateTestCert(t, "Corp Root CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	certPath := filepath.Join(tmpDir, "corp.pem")
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	opts := AddCertOptions{
+		Tags:  []string{"corp", " vpn ", "corp", ""},
+		Note:  "Issued by IT",
+		Owner: "secops@corp.example",
+	}
+	if err := store.AddCertWithOptions(ctx, certPath, "corp", opts); err != nil {
+		t.Fatalf("AddCertWithOptions() error = %v", err)
+	}
+
+	info, err := store.GetCertInfo("corp")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+	if !slices.Equal(info.Tags, []string{"corp", "vpn"}) {
+		t.Errorf("Tags = %v, want [corp vpn]", info.Tags)
+	}
+	if info.Note != "Issued by IT" || info.Owner != "secops@corp.example" {
+		t.Errorf("Note = %q, Owner = %q", info.Note, info.Owner)
+	}
+	if !info.HasTags([]string{"vpn", "corp"}) || info.HasTags([]string{"corp", "proxy"}) {
+		t.Error("HasTags() should require every tag")
+	}
+
+	t.Run("labels survive rebuild", func(t *testing.T) {
+		if err := store.UpdateMetadata(ctx, func(md *Metadata) error {
+			return store.RebuildBundle(ctx, md)
+		}); err != nil {
+			t.Fatalf("RebuildBundle() error = %v", err)
+		}
+		info, err := store.GetCertInfo("corp")
+		if err != nil {
+			t.Fatalf("GetCertInfo() error = %v", err)
+		}
+		if len(info.Tags) != 2 || info.Owner == "" || info.Note == "" {
+			t.Errorf("labels lost after rebuild: %+v", info)
+		}
+	})
+
+	t.Run("labels survive re-add without labels", func(t *testing.T) {
+		if err := store.AddCertWithOptions(ctx, certPath, "corp", AddCertOptions{}); err != nil {
+			t.Fatalf("AddCertWithOptions() error = %v", err)
+		}
+		info, err := store.GetCertInfo("corp")
+		if err != nil {
+			t.Fatalf("GetCertInfo() error = %v", err)
+		}
+		if !slices.Equal(info.Tags, []string{"corp", "vpn"}) || info.Owner != "secops@corp.example" {
+			t.Errorf("labels lost after re-add: %+v", info)
+		}
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		owner := ""
+		note := "Rotated 2026"
+		edited, err := store.EditCertLabels(ctx, "corp", CertLabelEdit{
+			AddTags:    []string{"proxy"},
+			RemoveTags: []string{"vpn"},
+			Note:       &note,
+			Owner:      &owner,
+		})
+		if err != nil {
+			t.Fatalf("EditCertLabels() error = %v", err)
+		}
+		if !slices.Equal(edited.Tags, []string{"corp", "proxy"}) {
+			t.Errorf("Tags = %v, want [corp proxy]", edited.Tags)
+		}
+		if edited.Note != note || edited.Owner != "" {
+			t.Errorf("Note = %q, Owner = %q", edited.Note, edited.Owner)
+		}
+
+		// Replacing with an empty list clears all tags
+		edited, err = store.EditCertLabels(ctx, "corp", CertLabelEdit{Tags: []string{}})
+		if err != nil {
+			t.Fatalf("EditCertLabels() error = %v", err)
+		}
+		if len(edited.Tags) != 0 || edited.Note != note {
+			t.Errorf("Tags = %v, Note = %q after clearing tags", edited.Tags, edited.Note)
+		}
+
+		stored, err := store.GetCertInfo("corp")
+		if err != nil {
+			t.Fatalf("GetCertInfo() error = %v", err)
+		}
+		if len(stored.Tags) != 0 || stored.Note != note {
+			t.Errorf("edit not persisted: %+v", stored)
+		}
+	})
+
+	t.Run("edit unknown certificate", func(t *testing.T) {
+		_, err := store.EditCertLabels(ctx, "missing", CertLabelEdit{AddTags: []string{"x"}})
+		if !errors.Is(err, verifierrors.ErrCertNotFound) {
+			t.Errorf("EditCertLabels() error = %v, want ErrCertNotFound", err)
+		}
+	})
+}
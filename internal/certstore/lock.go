@@ -34,7 +34,21 @@ func (l *FileLock) Lock(ctx context.Context) error {
 	return nil
 }
 
-// Unlock releases the file lock.
+// RLock acquires a shared lock with context support. Any number of shared
+// locks can be held at once, but not together with an exclusive lock, so
+// readers holding one never see a write in progress.
+func (l *FileLock) RLock(ctx context.Context) error {
+	locked, err := l.lock.TryRLockContext(ctx, 100*time.Millisecond)
+	if err != nil {
+		return fmt.Errorf("failed to acquire shared lock: %w", err)
+	}
+	if !locked {
+		return fmt.Errorf("failed to acquire shared lock: timeout")
+	}
+	return nil
+}
+
+// Unlock releases the file lock, exclusive or shared.
 func (l *FileLock) Unlock() error {
 	return l.lock.Unlock()
 }
@@ -241,3 +241,45 @@ func TestFileLock_MultipleFiles(t *testing.T) {
 	// Both should be held at the same time
 	t.Log("Both locks acquired successfully")
 }
+
+func TestFileLock_SharedLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	lockPath := filepath.Join(tmpDir, "test.lock")
+	ctx := context.Background()
+
+	reader1 := NewFileLock(lockPath)
+	reader2 := NewFileLock(lockPath)
+	writer := NewFileLock(lockPath)
+
+	// Shared locks do not exclude each other
+	if err := reader1.RLock(ctx); err != nil {
+		t.Fatalf("first RLock() failed: %v", err)
+	}
+	if err := reader2.RLock(ctx); err != nil {
+		t.Fatalf("second RLock() failed: %v", err)
+	}
+
+	// ...but do exclude a writer
+	ctx2, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	if err := writer.Lock(ctx2); err == nil {
+		t.Error("Lock() should fail while shared locks are held")
+		_ = writer.Unlock()
+	}
+
+	_ = reader1.Unlock()
+	_ = reader2.Unlock()
+
+	// A writer in turn excludes readers
+	if err := writer.Lock(ctx); err != nil {
+		t.Fatalf("Lock() failed after readers released: %v", err)
+	}
+	defer func() { _ = writer.Unlock() }()
+
+	ctx3, cancel3 := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel3()
+	if err := reader1.RLock(ctx3); err == nil {
+		t.Error("RLock() should fail while an exclusive lock is held")
+		_ = reader1.Unlock()
+	}
+}
@@ -13,40 +13,135 @@ import (
 // and user-added certificates.
 type Metadata struct {
 	Version        string         `json:"version"`
+	WrittenBy      string         `json:"written_by,omitempty"` // verifi version that last wrote the store
 	CombinedBundle BundleInfo     `json:"combined_bundle"`
 	MozillaBundle  BundleInfo     `json:"mozilla_bundle"`
 	UserCerts      []UserCertInfo `json:"user_certs"`
+
+	// Added in schema v3
+	Retention     *PrunePolicy `json:"retention,omitempty"`      // applied after every change
+	BundleMirrors []string     `json:"bundle_mirrors,omitempty"` // tried in order by bundle update
+
+	// migratedFrom is the schema version read from disk when it differed
+	// from currentSchemaVersion (0 otherwise).
+	migratedFrom int
 }
 
 // BundleInfo contains information about a certificate bundle.
 type BundleInfo struct {
-	Generated time.Time `json:"generated"`
-	SHA256    string    `json:"sha256"`
-	CertCount int       `json:"cert_count"`
-	Sources   []string  `json:"sources,omitempty"`
-	Version   string    `json:"version,omitempty"`
-	Source    string    `json:"source,omitempty"`
+	Generated  time.Time       `json:"generated"`
+	SHA256     string          `json:"sha256"`
+	CertCount  int             `json:"cert_count"`
+	Sources    []string        `json:"sources,omitempty"`
+	Version    string          `json:"version,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	Duplicates []DuplicateCert `json:"duplicates,omitempty"`
+	// ChecksumSources lists what a downloaded Mozilla bundle was verified
+	// against: the URL of its published checksum and/or "pinned".
+	ChecksumSources []string `json:"checksum_sources,omitempty"`
+	// ETag and LastModified are the HTTP cache validators of a downloaded
+	// Mozilla bundle, sent with the next update so an unchanged bundle is
+	// not downloaded again.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	// AgreedSources lists the sources that served the same certificates
+	// when a Mozilla bundle was fetched with a quorum.
+	AgreedSources []string `json:"agreed_sources,omitempty"`
+}
+
+// DuplicateCert describes a certificate that is provided by more than one
+// source. Sources are "mozilla" or "user:<name>".
+type DuplicateCert struct {
+	Subject     string   `json:"subject"`
+	Fingerprint string   `json:"fingerprint"`
+	Sources     []string `json:"sources"`
 }
 
 // UserCertInfo contains information about a user-added certificate.
+// A single entry may hold several certificates (e.g. a root plus intermediates);
+// Subject, Fingerprint and Role then describe the first certificate in the file,
+// Expires is the earliest expiry in the group, and Certificates lists them all.
 type UserCertInfo struct {
-	Name        string    `json:"name"`
-	Path        string    `json:"path"`
-	Added       time.Time `json:"added"`
+	Name         string        `json:"name"`
+	Path         string        `json:"path"`
+	Added        time.Time     `json:"added"`
+	Fingerprint  string        `json:"fingerprint"`
+	Subject      string        `json:"subject"`
+	Expires      time.Time     `json:"expires"`
+	Role         CertRole      `json:"role,omitempty"`
+	SourceFormat CertFormat    `json:"source_format,omitempty"`
+	Certificates []CertSummary `json:"certificates,omitempty"`
+
+	// Added in schema v2
+	Issuer             string    `json:"issuer,omitempty"`
+	Serial             string    `json:"serial,omitempty"`
+	NotBefore          time.Time `json:"not_before,omitempty"`
+	KeyAlgorithm       string    `json:"key_algorithm,omitempty"`
+	KeySize            int       `json:"key_size,omitempty"`
+	SignatureAlgorithm string    `json:"signature_algorithm,omitempty"`
+	SPKIHash           string    `json:"spki_sha256,omitempty"`
+
+	// Added in schema v3
+	Tags       []string       `json:"tags,omitempty"`
+	Note       string         `json:"note,omitempty"`
+	Owner      string         `json:"owner,omitempty"`
+	Disabled   bool           `json:"disabled,omitempty"`   // excluded from the combined bundle
+	Revisions  []CertRevision `json:"revisions,omitempty"`  // replaced versions, oldest first
+	TrustUntil time.Time      `json:"trust_until,omitzero"` // temporary trust; zero means no limit
+}
+
+// TrustLapsed reports whether the entry was added with temporary trust that
+// has ended at now. Lapsed entries are left out of the combined bundle.
+func (c UserCertInfo) TrustLapsed(now time.Time) bool {
+	return !c.TrustUntil.IsZero() && !now.Before(c.TrustUntil)
+}
+
+// CertRevision is a previous version of a user certificate, recorded when it
+// is replaced. During the rotation overlap, until RetireAt, the revision is
+// kept in the combined bundle alongside its replacement. Once it has been
+// retired its file is deleted and Path is cleared; the entry remains as history.
+type CertRevision struct {
+	Path        string    `json:"path,omitempty"`
 	Fingerprint string    `json:"fingerprint"`
 	Subject     string    `json:"subject"`
 	Expires     time.Time `json:"expires"`
+	Added       time.Time `json:"added"`
+	Replaced    time.Time `json:"replaced"`
+	RetireAt    time.Time `json:"retire_at"`
+}
+
+// Active reports whether the revision is still in its overlap window at now.
+func (r CertRevision) Active(now time.Time) bool {
+	return r.Path != "" && now.Before(r.RetireAt)
+}
+
+// CertSummary describes one certificate within a multi-certificate user entry.
+type CertSummary struct {
+	Subject     string    `json:"subject"`
+	Fingerprint string    `json:"fingerprint"`
+	Expires     time.Time `json:"expires"`
+	IsCA        bool      `json:"is_ca"`
+	Role        CertRole  `json:"role,omitempty"`
+}
+
+// CertCount returns the number of certificates held by the entry.
+func (c UserCertInfo) CertCount() int {
+	if len(c.Certificates) == 0 {
+		return 1
+	}
+	return len(c.Certificates)
 }
 
 const (
 	// currentSchemaVersion is the current metadata schema version.
-	currentSchemaVersion = "1"
+	currentSchemaVersion = "3"
 )
 
 // NewMetadata creates a new metadata instance with default values.
 func NewMetadata() *Metadata {
 	return &Metadata{
 		Version:   currentSchemaVersion,
+		WrittenBy: WriterVersion,
 		UserCerts: []UserCertInfo{},
 	}
 }
@@ -71,10 +166,14 @@ func (s *Store) readMetadata() (*Metadata, error) {
 		}
 	}
 
-	// Migrate if needed
+	// Migrate if needed (in memory; the result is persisted on the next write)
 	if m.Version != currentSchemaVersion {
-		if err := migrateMetadata(&m); err != nil {
-			return nil, fmt.Errorf("migrate metadata: %w", err)
+		if err := s.migrateMetadata(&m); err != nil {
+			return nil, &verifierrors.VerifiError{
+				Op:   "migrate metadata",
+				Path: s.metadataPath(),
+				Err:  err,
+			}
 		}
 	}
 
@@ -82,15 +181,47 @@ func (s *Store) readMetadata() (*Metadata, error) {
 }
 
 // writeMetadata writes the metadata to metadata.json using atomic rename.
+// If m was migrated from an older schema, the original file is backed up
+// first. Metadata with a schema newer than this binary is never written.
 func (s *Store) writeMetadata(m *Metadata) error {
+	data, err := s.encodeMetadata(m)
+	if err != nil {
+		return err
+	}
+	return s.writeMetadataData(data)
+}
+
+// encodeMetadata prepares m for writing and returns the bytes to write. It
+// refuses newer schemas and backs up metadata migrated from an older one.
+func (s *Store) encodeMetadata(m *Metadata) ([]byte, error) {
+	if m.IsNewerSchema() {
+		return nil, &verifierrors.VerifiError{
+			Op:   "write metadata",
+			Path: s.metadataPath(),
+			Err:  fmt.Errorf("%w: schema %s, this verifi supports up to %s", verifierrors.ErrMetadataTooNew, m.Version, currentSchemaVersion),
+		}
+	}
+
+	if m.migratedFrom != 0 {
+		if err := s.backupMetadata(m.migratedFrom); err != nil {
+			return nil, err
+		}
+	}
+
+	m.WrittenBy = WriterVersion
+
 	data, err := json.MarshalIndent(m, "", "  ")
 	if err != nil {
-		return &verifierrors.VerifiError{
+		return nil, &verifierrors.VerifiError{
 			Op:  "marshal metadata",
 			Err: err,
 		}
 	}
+	return data, nil
+}
 
+// writeMetadataData replaces metadata.json with data using atomic rename.
+func (s *Store) writeMetadataData(data []byte) error {
 	// Write to temp file
 	tempPath := s.metadataPath() + ".tmp"
 	if err := s.fs.WriteFile(tempPath, data, 0644); err != nil {
@@ -115,33 +246,10 @@ func (s *Store) writeMetadata(m *Metadata) error {
 }
 
 // UpdateMetadata updates the metadata using the provided function.
-// It acquires a lock before reading/writing to ensure concurrent safety.
+// It runs as a store transaction (see update), so it holds the store lock
+// and recovers any operation interrupted by an earlier crash first.
 func (s *Store) UpdateMetadata(ctx context.Context, fn func(*Metadata) error) error {
-	lock := NewFileLock(s.metadataPath())
-	if err := lock.Lock(ctx); err != nil {
-		return fmt.Errorf("failed to lock metadata: %w", err)
-	}
-	defer func() { _ = lock.Unlock() }()
-
-	// Read current metadata
-	metadata, err := s.readMetadata()
-	if err != nil {
-		return err
-	}
-
-	// Apply changes
-	if err := fn(metadata); err != nil {
-		return err
-	}
-
-	// Write updated metadata
-	return s.writeMetadata(metadata)
-}
-
-// migrateMetadata handles schema version migrations.
-func migrateMetadata(m *Metadata) error {
-	// Currently only v1 exists, so no migrations needed yet
-	// Future versions would add migration logic here
-	m.Version = currentSchemaVersion
-	return nil
+	return s.update(ctx, "update metadata", func(tx *storeTx) error {
+		return fn(tx.md)
+	})
 }
@@ -12,14 +12,15 @@ import (
 	"testing"
 	"time"
 
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
 	"github.com/princespaghetti/verifi/internal/fetcher"
 )
 
 func TestNewMetadata_Defaults(t *testing.T) {
 	metadata := NewMetadata()
 
-	if metadata.Version != "1" {
-		t.Errorf("Version = %q, want %q", metadata.Version, "1")
+	if metadata.Version != "3" {
+		t.Errorf("Version = %q, want %q", metadata.Version, "3")
 	}
 
 	if len(metadata.UserCerts) != 0 {
@@ -403,19 +404,22 @@ func TestUpdateMetadata_ConcurrentAccess(t *testing.T) {
 }
 
 func TestMigrateMetadata_NoOp(t *testing.T) {
-	// Test that v1 to v1 migration is a no-op
+	// Test that migrating metadata already at the current version is a no-op
+	store := &Store{basePath: t.TempDir(), fs: &OSFileSystem{}}
 	metadata := NewMetadata()
-	metadata.Version = "1"
 	metadata.MozillaBundle.CertCount = 100
 
-	err := migrateMetadata(metadata)
+	err := store.migrateMetadata(metadata)
 	if err != nil {
 		t.Errorf("migrateMetadata() failed: %v", err)
 	}
 
-	// Version should still be 1
-	if metadata.Version != "1" {
-		t.Errorf("Version = %q, want %q", metadata.Version, "1")
+	// Version should be unchanged
+	if metadata.Version != currentSchemaVersion {
+		t.Errorf("Version = %q, want %q", metadata.Version, currentSchemaVersion)
+	}
+	if metadata.MigratedFrom() != 0 {
+		t.Errorf("MigratedFrom() = %d, want 0", metadata.MigratedFrom())
 	}
 
 	// Data should be unchanged
@@ -423,3 +427,179 @@ func TestMigrateMetadata_NoOp(t *testing.T) {
 		t.Errorf("CertCount changed during migration: %d", metadata.MozillaBundle.CertCount)
 	}
 }
+
+func TestMigrateMetadata_V1ToV2(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	// Simulate a v1 store: a user cert file plus a v1 metadata entry
+	certPEM := generateTestCert(t, "Legacy CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	if err := os.WriteFile(store.userCertPath("legacy"), certPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	v1 := map[string]any{
+		"version":         "1",
+		"combined_bundle": map[string]any{"sha256": "abc", "cert_count": 1},
+		"mozilla_bundle":  map[string]any{"sha256": "def", "cert_count": 1},
+		"user_certs": []map[string]any{{
+			"name":        "legacy",
+			"path":        "user/legacy.pem",
+			"fingerprint": "sha256:old",
+			"subject":     "CN=Legacy CA",
+		}},
+	}
+	v1Data, err := json.MarshalIndent(v1, "", "  ")
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := os.WriteFile(store.metadataPath(), v1Data, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// Reading migrates in memory without touching the file
+	metadata, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	if metadata.Version != currentSchemaVersion || metadata.MigratedFrom() != 1 {
+		t.Errorf("Version = %q, MigratedFrom() = %d, want %s and 1", metadata.Version, metadata.MigratedFrom(), currentSchemaVersion)
+	}
+	info := metadata.UserCerts[0]
+	if info.Issuer != "CN=Legacy CA" || info.Serial == "" || info.NotBefore.IsZero() {
+		t.Errorf("issuer/serial/not_before not migrated: %+v", info)
+	}
+	if info.KeyAlgorithm != "ECDSA" || info.KeySize != 256 {
+		t.Errorf("key = %s/%d, want ECDSA/256", info.KeyAlgorithm, info.KeySize)
+	}
+	if info.SignatureAlgorithm != "ECDSA-SHA256" {
+		t.Errorf("SignatureAlgorithm = %q, want ECDSA-SHA256", info.SignatureAlgorithm)
+	}
+	if !strings.HasPrefix(info.SPKIHash, "sha256:") || info.Role != RoleRoot {
+		t.Errorf("SPKIHash = %q, Role = %q", info.SPKIHash, info.Role)
+	}
+	if _, err := os.Stat(store.metadataPath() + ".v1.bak"); !os.IsNotExist(err) {
+		t.Error("reading metadata should not create a backup")
+	}
+
+	// The first write backs up the v1 file and persists the current schema
+	WriterVersion = "1.2.3"
+	defer func() { WriterVersion = "dev" }()
+	if err := store.UpdateMetadata(ctx, func(md *Metadata) error { return nil }); err != nil {
+		t.Fatalf("UpdateMetadata() error = %v", err)
+	}
+
+	backup, err := os.ReadFile(store.metadataPath() + ".v1.bak")
+	if err != nil {
+		t.Fatalf("backup not created: %v", err)
+	}
+	if string(backup) != string(v1Data) {
+		t.Error("backup should hold the original v1 metadata")
+	}
+
+	written, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	if written.Version != currentSchemaVersion || written.MigratedFrom() != 0 {
+		t.Errorf("persisted Version = %q, MigratedFrom() = %d", written.Version, written.MigratedFrom())
+	}
+	if written.WrittenBy != "1.2.3" {
+		t.Errorf("WrittenBy = %q, want 1.2.3", written.WrittenBy)
+	}
+}
+
+func TestMigrateMetadata_V2ToV3(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	v2 := `{"version": "2", "combined_bundle": {}, "mozilla_bundle": {"cert_count": 100}, "user_certs": []}`
+	if err := os.WriteFile(store.metadataPath(), []byte(v2), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	metadata, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	if metadata.Version != "3" || metadata.MigratedFrom() != 2 {
+		t.Errorf("Version = %q, MigratedFrom() = %d, want 3 and 2", metadata.Version, metadata.MigratedFrom())
+	}
+	if metadata.MozillaBundle.CertCount != 100 || metadata.Retention != nil {
+		t.Errorf("migration changed data: %+v", metadata)
+	}
+}
+
+func TestUpdateMetadata_RefusesNewerSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	future := `{"version": "99", "combined_bundle": {}, "mozilla_bundle": {}, "user_certs": [], "new_field": true}`
+	if err := os.WriteFile(store.metadataPath(), []byte(future), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// Reading still works for read-only commands
+	metadata, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() error = %v", err)
+	}
+	if !metadata.IsNewerSchema() {
+		t.Error("IsNewerSchema() = false, want true")
+	}
+
+	called := false
+	err = store.UpdateMetadata(ctx, func(md *Metadata) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, verifierrors.ErrMetadataTooNew) {
+		t.Errorf("UpdateMetadata() error = %v, want ErrMetadataTooNew", err)
+	}
+	if called {
+		t.Error("update function should not run for a newer schema")
+	}
+
+	certPath := filepath.Join(tmpDir, "cert.pem")
+	certPEM := generateTestCert(t, "Corp CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, certPath, "corp", false); !errors.Is(err, verifierrors.ErrMetadataTooNew) {
+		t.Errorf("AddCert() error = %v, want ErrMetadataTooNew", err)
+	}
+	if _, err := os.Stat(store.userCertPath("corp")); !os.IsNotExist(err) {
+		t.Error("AddCert() should not write files for a newer schema")
+	}
+
+	data, err := os.ReadFile(store.metadataPath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != future {
+		t.Error("metadata from a newer schema must not be rewritten")
+	}
+}
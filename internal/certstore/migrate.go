@@ -0,0 +1,200 @@
+package certstore
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// WriterVersion is the verifi version recorded in metadata on every write.
+// The CLI sets it from its build version.
+var WriterVersion = "dev"
+
+// migration upgrades metadata from one schema version to the next.
+type migration func(s *Store, m *Metadata) error
+
+// migrations maps a schema version to the migration that upgrades it to the
+// following version. Add an entry here for every schema change.
+var migrations = map[int]migration{
+	1: migrateV1ToV2,
+	2: migrateV2ToV3,
+}
+
+// schemaVersion parses a metadata schema version string.
+func schemaVersion(version string) (int, error) {
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid schema version %q", version)
+	}
+	return v, nil
+}
+
+// IsNewerSchema reports whether the metadata was written by a newer verifi
+// with a schema this binary does not understand. Such metadata can be read
+// on a best-effort basis but must not be written.
+func (m *Metadata) IsNewerSchema() bool {
+	v, err := schemaVersion(m.Version)
+	if err != nil {
+		return false
+	}
+	current, _ := schemaVersion(currentSchemaVersion)
+	return v > current
+}
+
+// MigratedFrom returns the schema version found on disk if the metadata was
+// migrated in memory, or 0 if no migration was needed.
+func (m *Metadata) MigratedFrom() int {
+	return m.migratedFrom
+}
+
+// CheckWritable returns ErrMetadataTooNew if the store was written by a newer
+// verifi. Operations that change files before updating metadata call it first.
+func (s *Store) CheckWritable() error {
+	m, err := s.readMetadata()
+	if err != nil {
+		return err
+	}
+	if m.IsNewerSchema() {
+		return &verifierrors.VerifiError{
+			Op:   "check metadata",
+			Path: s.metadataPath(),
+			Err:  fmt.Errorf("%w: schema %s, this verifi supports up to %s", verifierrors.ErrMetadataTooNew, m.Version, currentSchemaVersion),
+		}
+	}
+	return nil
+}
+
+// migrateMetadata runs the migration chain from m.Version up to
+// currentSchemaVersion. Metadata from a newer schema is left as is; writes
+// are refused by writeMetadata instead.
+func (s *Store) migrateMetadata(m *Metadata) error {
+	from, err := schemaVersion(m.Version)
+	if err != nil {
+		return err
+	}
+	current, _ := schemaVersion(currentSchemaVersion)
+	if from >= current {
+		return nil
+	}
+
+	for v := from; v < current; v++ {
+		migrate, ok := migrations[v]
+		if !ok {
+			return fmt.Errorf("no migration from schema version %d", v)
+		}
+		if err := migrate(s, m); err != nil {
+			return fmt.Errorf("migrate schema %d to %d: %w", v, v+1, err)
+		}
+		m.Version = strconv.Itoa(v + 1)
+	}
+
+	m.migratedFrom = from
+	return nil
+}
+
+// backupMetadata copies metadata.json to metadata.json.v<version>.bak before
+// a migrated schema is written. An existing backup is kept.
+func (s *Store) backupMetadata(version int) error {
+	backupPath := fmt.Sprintf("%s.v%d.bak", s.metadataPath(), version)
+	if _, err := s.fs.Stat(backupPath); err == nil {
+		return nil
+	}
+
+	data, err := s.fs.ReadFile(s.metadataPath())
+	if err != nil {
+		return &verifierrors.VerifiError{
+			Op:   "read metadata for backup",
+			Path: s.metadataPath(),
+			Err:  err,
+		}
+	}
+
+	if err := s.fs.WriteFile(backupPath, data, 0644); err != nil {
+		return &verifierrors.VerifiError{
+			Op:   "backup metadata",
+			Path: backupPath,
+			Err:  err,
+		}
+	}
+
+	return nil
+}
+
+// migrateV1ToV2 fills in the certificate details added in schema v2 from
+// the stored user certificate files. Entries whose file is missing or
+// unreadable are left without the new fields; doctor reports those.
+func migrateV1ToV2(s *Store, m *Metadata) error {
+	for i := range m.UserCerts {
+		info := &m.UserCerts[i]
+
+		data, err := s.fs.ReadFile(filepath.Join(s.basePath, "certs", info.Path))
+		if err != nil {
+			continue
+		}
+		certs, metas, err := ValidateCertChain(data, true)
+		if err != nil {
+			continue
+		}
+
+		applyCertDetails(info, certs[0])
+		if info.Role == "" {
+			info.Role = metas[0].Role
+		}
+		for j := range info.Certificates {
+			if j < len(metas) && info.Certificates[j].Role == "" {
+				info.Certificates[j].Role = metas[j].Role
+			}
+		}
+	}
+	return nil
+}
+
+// migrateV2ToV3 has nothing to convert. Schema v3 only adds optional fields
+// that are empty for existing stores: labels, the disabled flag, revisions,
+// temporary trust, the retention policy, bundle mirrors and the download
+// details of the Mozilla bundle. The bump makes releases that know schema v2
+// refuse to write the store (see CheckWritable), as they would drop these
+// fields; releases from before schema v2 do not check the version.
+func migrateV2ToV3(s *Store, m *Metadata) error {
+	return nil
+}
+
+// applyCertDetails sets the schema v2 certificate details on info.
+func applyCertDetails(info *UserCertInfo, cert *x509.Certificate) {
+	info.Issuer = cert.Issuer.String()
+	info.Serial = cert.SerialNumber.Text(16)
+	info.NotBefore = cert.NotBefore
+	info.KeyAlgorithm, info.KeySize = publicKeyInfo(cert)
+	info.SignatureAlgorithm = cert.SignatureAlgorithm.String()
+	info.SPKIHash = SPKIHash(cert)
+}
+
+// SPKIHash returns the SHA256 hash of the certificate's SubjectPublicKeyInfo
+// in "sha256:<hex>" form. Unlike the fingerprint, it stays the same when a CA
+// is re-issued with the same key.
+func SPKIHash(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// publicKeyInfo returns the public key algorithm and size in bits.
+func publicKeyInfo(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0
+	}
+}
@@ -0,0 +1,365 @@
+package certstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// staleTempAge is how old a temporary file must be before Prune or
+// CleanTempFiles removes it, so a write in progress in another process is
+// left alone.
+const staleTempAge = 10 * time.Minute
+
+// PrunePolicy selects what Prune removes. Stored in metadata as the
+// retention policy, it is applied automatically after commands that change
+// the store.
+type PrunePolicy struct {
+	// Expired removes certificates that have expired.
+	Expired bool
+	// Grace is how long a certificate must have been expired before Expired
+	// removes it. It is stored as a duration string such as "30d".
+	Grace time.Duration
+	// Lapsed removes certificates whose temporary trust has ended.
+	Lapsed bool
+	// Orphans removes files in certs/user that no entry refers to, entries
+	// whose file is missing, and stale temporary and lock files.
+	Orphans bool
+}
+
+// prunePolicyJSON is the JSON form of PrunePolicy.
+type prunePolicyJSON struct {
+	Expired bool            `json:"expired,omitempty"`
+	Grace   json.RawMessage `json:"grace,omitempty"`
+	Lapsed  bool            `json:"lapsed,omitempty"`
+	Orphans bool            `json:"orphans,omitempty"`
+}
+
+// MarshalJSON writes Grace as a duration string (see FormatDuration), so
+// the stored policy reads as it was given on the command line.
+func (p PrunePolicy) MarshalJSON() ([]byte, error) {
+	out := prunePolicyJSON{Expired: p.Expired, Lapsed: p.Lapsed, Orphans: p.Orphans}
+	if p.Grace > 0 {
+		grace, err := json.Marshal(FormatDuration(p.Grace))
+		if err != nil {
+			return nil, err
+		}
+		out.Grace = grace
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads Grace as a duration string (see ParseDuration).
+func (p *PrunePolicy) UnmarshalJSON(data []byte) error {
+	var in prunePolicyJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*p = PrunePolicy{Expired: in.Expired, Lapsed: in.Lapsed, Orphans: in.Orphans}
+	if len(in.Grace) == 0 {
+		return nil
+	}
+
+	var grace string
+	if err := json.Unmarshal(in.Grace, &grace); err != nil {
+		return fmt.Errorf("invalid retention grace %s", in.Grace)
+	}
+	d, err := ParseDuration(grace)
+	if err != nil {
+		return fmt.Errorf("invalid retention grace: %w", err)
+	}
+	p.Grace = d
+	return nil
+}
+
+// IsZero reports whether the policy selects nothing.
+func (p PrunePolicy) IsZero() bool {
+	return !p.Expired && !p.Lapsed && !p.Orphans
+}
+
+// PruneKind describes why an item is pruned.
+type PruneKind string
+
+// Prune kinds.
+const (
+	PruneExpired   PruneKind = "expired"   // certificate expired (past the grace period)
+	PruneLapsed    PruneKind = "lapsed"    // temporary trust ended
+	PruneMissing   PruneKind = "missing"   // metadata entry whose file is gone
+	PruneUntracked PruneKind = "untracked" // file in certs/user without an entry
+	PruneTemp      PruneKind = "temp"      // stale temporary or lock file
+)
+
+// PruneItem is one entry or file that Prune removes. Name is set for
+// metadata entries; Path is relative to the store directory.
+type PruneItem struct {
+	Kind PruneKind `json:"kind"`
+	Name string    `json:"name,omitempty"`
+	Path string    `json:"path"`
+	// Since is when the certificate expired or its trust lapsed.
+	Since time.Time `json:"since,omitzero"`
+}
+
+// PlanPrune returns what Prune would remove under policy, without changing
+// anything.
+func (s *Store) PlanPrune(policy PrunePolicy) ([]PruneItem, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "plan prune",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+	return s.planPrune(metadata, policy, time.Now()), nil
+}
+
+// Prune removes what policy selects and returns the removed items. The plan
+// is recomputed under the store lock, so it may differ from an earlier
+// PlanPrune. Removed entries take their files (including retiring revisions)
+// with them, and the combined bundle is rebuilt when any entry is removed.
+func (s *Store) Prune(ctx context.Context, policy PrunePolicy) ([]PruneItem, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "prune store",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	// Avoid taking the lock when there is nothing to do
+	items, err := s.PlanPrune(policy)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	err = s.update(ctx, "prune store", func(tx *storeTx) error {
+		md := tx.md
+		items = s.planPrune(md, policy, time.Now())
+
+		remove := make(map[string]bool)
+		for _, item := range items {
+			switch item.Kind {
+			case PruneExpired, PruneLapsed, PruneMissing:
+				remove[item.Name] = true
+			default:
+				if err := tx.remove(filepath.Join(s.basePath, item.Path)); err != nil {
+					return err
+				}
+			}
+		}
+		if len(remove) == 0 {
+			return nil
+		}
+
+		kept := md.UserCerts[:0]
+		for _, info := range md.UserCerts {
+			if !remove[info.Name] {
+				kept = append(kept, info)
+				continue
+			}
+			if err := tx.remove(filepath.Join(s.basePath, "certs", info.Path)); err != nil {
+				return err
+			}
+			for _, rev := range info.Revisions {
+				if rev.Path != "" {
+					if err := tx.remove(filepath.Join(s.basePath, "certs", rev.Path)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		md.UserCerts = kept
+
+		return s.RebuildBundle(ctx, md)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// planPrune lists what policy selects in md at now.
+func (s *Store) planPrune(md *Metadata, policy PrunePolicy, now time.Time) []PruneItem {
+	var items []PruneItem
+
+	tracked := make(map[string]bool)
+	for _, info := range md.UserCerts {
+		tracked[info.Path] = true
+		for _, rev := range info.Revisions {
+			if rev.Path != "" {
+				tracked[rev.Path] = true
+			}
+		}
+
+		item := PruneItem{Name: info.Name, Path: filepath.Join("certs", info.Path)}
+		_, statErr := s.fs.Stat(filepath.Join(s.basePath, "certs", info.Path))
+		switch {
+		case policy.Orphans && statErr != nil:
+			item.Kind = PruneMissing
+		case policy.Lapsed && info.TrustLapsed(now):
+			item.Kind = PruneLapsed
+			item.Since = info.TrustUntil
+		case policy.Expired && !now.Before(info.Expires.Add(policy.Grace)):
+			item.Kind = PruneExpired
+			item.Since = info.Expires
+		default:
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if !policy.Orphans {
+		return items
+	}
+
+	for _, dir := range []string{"user", "user/retiring"} {
+		entries, err := s.fs.ReadDir(filepath.Join(s.basePath, "certs", filepath.FromSlash(dir)))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			rel := dir + "/" + entry.Name()
+			if entry.IsDir() || tracked[rel] || isTempFile(entry.Name()) {
+				continue
+			}
+			items = append(items, PruneItem{Kind: PruneUntracked, Path: filepath.Join("certs", filepath.FromSlash(rel))})
+		}
+	}
+
+	temps, _ := s.staleTempFiles(now) // Ignore error - temporary files are best effort
+	for _, path := range temps {
+		items = append(items, PruneItem{Kind: PruneTemp, Path: path})
+	}
+
+	return items
+}
+
+// tempFiles returns every temporary (*.tmp) and lock (*.lock) file in the
+// store directory tree, as absolute paths.
+func (s *Store) tempFiles() ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(s.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if isTempFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// isTempFile reports whether name is a temporary or lock file.
+func isTempFile(name string) bool {
+	return strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".lock")
+}
+
+// staleTempFiles returns, relative to the store directory, the temporary
+// files older than staleTempAge and the lock files whose locked file no
+// longer exists.
+func (s *Store) staleTempFiles(now time.Time) ([]string, error) {
+	files, err := s.tempFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, path := range files {
+		if target, ok := strings.CutSuffix(path, ".lock"); ok {
+			if _, err := s.fs.Stat(target); err == nil {
+				continue
+			}
+		} else if info, err := s.fs.Stat(path); err != nil || now.Sub(info.ModTime()) < staleTempAge {
+			continue
+		}
+
+		rel, err := filepath.Rel(s.basePath, path)
+		if err != nil {
+			continue
+		}
+		stale = append(stale, rel)
+	}
+	return stale, nil
+}
+
+// CleanTempFiles removes stale temporary and lock files (see staleTempFiles)
+// and returns their paths relative to the store directory. It runs under the
+// store lock after recovery, so files that an interrupted or running
+// operation still needs are never removed.
+func (s *Store) CleanTempFiles(ctx context.Context) ([]string, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "clean temporary files",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	// Avoid taking the lock when there is nothing to do
+	if stale, err := s.staleTempFiles(time.Now()); err != nil || len(stale) == 0 {
+		return nil, err
+	}
+
+	var removed []string
+	err := s.update(ctx, "clean temporary files", func(tx *storeTx) error {
+		stale, err := s.staleTempFiles(time.Now())
+		if err != nil {
+			return err
+		}
+		for _, path := range stale {
+			if err := tx.remove(filepath.Join(s.basePath, path)); err != nil {
+				return err
+			}
+		}
+		removed = stale
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// SetRetentionPolicy stores policy as the retention policy. A nil or empty
+// policy clears it.
+func (s *Store) SetRetentionPolicy(ctx context.Context, policy *PrunePolicy) error {
+	if policy != nil && policy.IsZero() {
+		policy = nil
+	}
+	return s.update(ctx, "set retention policy", func(tx *storeTx) error {
+		tx.md.Retention = policy
+		return nil
+	})
+}
+
+// ApplyRetention prunes the store according to its retention policy, if one
+// is configured, and returns the removed items.
+func (s *Store) ApplyRetention(ctx context.Context) ([]PruneItem, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "apply retention",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Retention == nil {
+		return nil, nil
+	}
+	return s.Prune(ctx, *metadata.Retention)
+}
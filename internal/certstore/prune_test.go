@@ -0,0 +1,262 @@
+package certstore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_Prune(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+	certsDir := filepath.Join(tmpDir, "certs")
+
+	// An expired certificate, a lapsed one, a stray file, a dangling entry
+	// and leftover temporary files
+	expiredPEM := generateTestCert(t, "Old CA", time.Now().Add(-400*24*time.Hour), time.Now().Add(-10*24*time.Hour))
+	expiredPath := filepath.Join(tmpDir, "old.pem")
+	if err := os.WriteFile(expiredPath, expiredPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, expiredPath, "old", true); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+
+	proxyPEM := generateTestCert(t, "Proxy CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	gonePEM := generateTestCert(t, "Gone CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	for name, data := range map[string][]byte{"proxy": proxyPEM, "gone": gonePEM} {
+		path := filepath.Join(tmpDir, name+".pem")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if err := store.AddCert(ctx, path, name, false); err != nil {
+			t.Fatalf("AddCert() error = %v", err)
+		}
+	}
+	if err := store.UpdateMetadata(ctx, func(md *Metadata) error {
+		for i := range md.UserCerts {
+			if md.UserCerts[i].Name == "proxy" {
+				md.UserCerts[i].TrustUntil = time.Now().Add(-time.Hour)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateMetadata() error = %v", err)
+	}
+	if err := os.Remove(filepath.Join(certsDir, "user", "gone.pem")); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	strayPath := filepath.Join(certsDir, "user", "stray.pem")
+	if err := os.WriteFile(strayPath, proxyPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	staleTemp := filepath.Join(certsDir, "bundles", "combined-bundle.pem.tmp")
+	if err := os.WriteFile(staleTemp, nil, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(staleTemp, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	freshTemp := filepath.Join(certsDir, "user", "new.pem.tmp")
+	if err := os.WriteFile(freshTemp, nil, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	staleLock := filepath.Join(certsDir, "user", "removed.pem.lock")
+	if err := os.WriteFile(staleLock, nil, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	kinds := func(items []PruneItem) map[PruneKind][]string {
+		out := make(map[PruneKind][]string)
+		for _, item := range items {
+			label := item.Name
+			if label == "" {
+				label = filepath.Base(item.Path)
+			}
+			out[item.Kind] = append(out[item.Kind], label)
+		}
+		return out
+	}
+
+	t.Run("grace period", func(t *testing.T) {
+		items, err := store.PlanPrune(PrunePolicy{Expired: true, Grace: 30 * 24 * time.Hour})
+		if err != nil {
+			t.Fatalf("PlanPrune() error = %v", err)
+		}
+		if len(items) != 0 {
+			t.Errorf("PlanPrune() = %+v, want nothing within the grace period", items)
+		}
+	})
+
+	policy := PrunePolicy{Expired: true, Lapsed: true, Orphans: true}
+	plan, err := store.PlanPrune(policy)
+	if err != nil {
+		t.Fatalf("PlanPrune() error = %v", err)
+	}
+	got := kinds(plan)
+	want := map[PruneKind][]string{
+		PruneExpired:   {"old"},
+		PruneLapsed:    {"proxy"},
+		PruneMissing:   {"gone"},
+		PruneUntracked: {"stray.pem"},
+		PruneTemp:      {"combined-bundle.pem.tmp", "removed.pem.lock"},
+	}
+	for kind, names := range want {
+		if len(got[kind]) != len(names) {
+			t.Errorf("plan[%s] = %v, want %v", kind, got[kind], names)
+			continue
+		}
+		for i := range names {
+			if got[kind][i] != names[i] {
+				t.Errorf("plan[%s] = %v, want %v", kind, got[kind], names)
+			}
+		}
+	}
+
+	// Planning changes nothing
+	if _, err := os.Stat(strayPath); err != nil {
+		t.Errorf("PlanPrune() should not delete files: %v", err)
+	}
+
+	items, err := store.Prune(ctx, policy)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(items) != len(plan) {
+		t.Errorf("Prune() removed %d items, want %d", len(items), len(plan))
+	}
+
+	certs, err := store.ListCerts()
+	if err != nil {
+		t.Fatalf("ListCerts() error = %v", err)
+	}
+	if len(certs) != 1 || certs[0].Name != "corp" {
+		t.Errorf("ListCerts() = %+v, want only corp", certs)
+	}
+	for _, path := range []string{strayPath, staleTemp, staleLock, store.userCertPath("old"), store.userCertPath("proxy")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be deleted, stat error = %v", path, err)
+		}
+	}
+	if _, err := os.Stat(freshTemp); err != nil {
+		t.Errorf("recent temporary file should be kept: %v", err)
+	}
+	if combinedBundleContains(t, store, "CN=Old CA") {
+		t.Error("pruned certificate should not be in the combined bundle")
+	}
+
+	// Nothing left to do
+	if items, err := store.Prune(ctx, policy); err != nil || len(items) != 0 {
+		t.Errorf("second Prune() = %+v, %v, want nothing", items, err)
+	}
+}
+
+func TestStore_ApplyRetention(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	strayPath := filepath.Join(tmpDir, "certs", "user", "stray.pem")
+	if err := os.WriteFile(strayPath, []byte("stray"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// No policy: nothing happens
+	if items, err := store.ApplyRetention(ctx); err != nil || len(items) != 0 {
+		t.Fatalf("ApplyRetention() = %+v, %v, want nothing without a policy", items, err)
+	}
+
+	if err := store.SetRetentionPolicy(ctx, &PrunePolicy{Orphans: true}); err != nil {
+		t.Fatalf("SetRetentionPolicy() error = %v", err)
+	}
+	md, err := store.GetMetadata()
+	if err != nil {
+		t.Fatalf("GetMetadata() error = %v", err)
+	}
+	if md.Retention == nil || !md.Retention.Orphans {
+		t.Fatalf("Retention = %+v, want orphans policy", md.Retention)
+	}
+
+	items, err := store.ApplyRetention(ctx)
+	if err != nil || len(items) != 1 || items[0].Kind != PruneUntracked {
+		t.Errorf("ApplyRetention() = %+v, %v, want the stray file", items, err)
+	}
+
+	// An empty policy clears it
+	if err := store.SetRetentionPolicy(ctx, &PrunePolicy{}); err != nil {
+		t.Fatalf("SetRetentionPolicy() error = %v", err)
+	}
+	if md, err := store.GetMetadata(); err != nil || md.Retention != nil {
+		t.Errorf("Retention = %+v, %v, want nil", md.Retention, err)
+	}
+}
+
+func TestStore_CleanTempFiles(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	write := func(rel string, age time.Duration) string {
+		t.Helper()
+		path := filepath.Join(tmpDir, rel)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Chtimes() error = %v", err)
+		}
+		return path
+	}
+	staleTemp := write(filepath.Join("certs", "bundles", "combined-bundle.pem.tmp"), time.Hour)
+	freshTemp := write(filepath.Join("certs", "user", "new.pem.tmp"), 0)
+	staleLock := write(filepath.Join("certs", "user", "removed.pem.lock"), time.Hour)
+	liveLock := write(filepath.Join("certs", "metadata.json.lock"), time.Hour)
+
+	removed, err := store.CleanTempFiles(ctx)
+	if err != nil {
+		t.Fatalf("CleanTempFiles() error = %v", err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("CleanTempFiles() = %v, want the stale temp and lock files", removed)
+	}
+	for _, path := range []string{staleTemp, staleLock} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", path)
+		}
+	}
+	for _, path := range []string{freshTemp, liveLock} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s should be kept: %v", path, err)
+		}
+	}
+}
+
+func TestPrunePolicy_JSON(t *testing.T) {
+	policy := PrunePolicy{Expired: true, Grace: 30 * 24 * time.Hour, Orphans: true}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"expired":true,"grace":"30d","orphans":true}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var got PrunePolicy
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got != policy {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, policy)
+	}
+
+	for _, grace := range []string{`"soon"`, `2592000000000000`} {
+		if err := json.Unmarshal([]byte(`{"grace":`+grace+`}`), &got); err == nil {
+			t.Errorf("Unmarshal() expected error for grace %s", grace)
+		}
+	}
+}
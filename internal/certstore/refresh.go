@@ -0,0 +1,101 @@
+package certstore
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// RefreshResult describes the time-based changes applied by Refresh.
+type RefreshResult struct {
+	// RetiredRevisions is the number of replaced certificates whose rotation
+	// overlap ended and which were removed from the combined bundle.
+	RetiredRevisions int
+	// LapsedTrust names the certificates whose temporary trust ended since
+	// the combined bundle was last built.
+	LapsedTrust []string
+}
+
+// Changed reports whether Refresh rebuilt the combined bundle.
+func (r *RefreshResult) Changed() bool {
+	return r.RetiredRevisions > 0 || len(r.LapsedTrust) > 0
+}
+
+// Refresh applies time-based changes to the store: revisions whose rotation
+// overlap has passed are retired (their files are deleted but they stay in
+// metadata as history), and certificates whose temporary trust has lapsed are
+// dropped from the combined bundle. Lapsed certificates keep their file and
+// metadata entry. When nothing has lapsed, nothing is written.
+func (s *Store) Refresh(ctx context.Context) (*RefreshResult, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "refresh store",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	// Avoid taking the lock when there is nothing to do
+	metadata, err := s.readMetadata()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if len(lapsedRevisions(metadata, now)) == 0 && len(lapsedTrust(metadata, now)) == 0 {
+		return &RefreshResult{}, nil
+	}
+
+	result := &RefreshResult{}
+	err = s.update(ctx, "refresh store", func(tx *storeTx) error {
+		md := tx.md
+		now := time.Now()
+		for _, rev := range lapsedRevisions(md, now) {
+			if err := tx.remove(filepath.Join(s.basePath, "certs", rev.Path)); err != nil {
+				return err
+			}
+			rev.Path = ""
+			result.RetiredRevisions++
+		}
+		result.LapsedTrust = lapsedTrust(md, now)
+		if !result.Changed() {
+			return nil
+		}
+		return s.RebuildBundle(ctx, md)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// lapsedRevisions returns the revisions in md whose overlap has passed but
+// whose file has not been deleted yet.
+func lapsedRevisions(md *Metadata, now time.Time) []*CertRevision {
+	var lapsed []*CertRevision
+	for i := range md.UserCerts {
+		for j := range md.UserCerts[i].Revisions {
+			rev := &md.UserCerts[i].Revisions[j]
+			if rev.Path != "" && !rev.Active(now) {
+				lapsed = append(lapsed, rev)
+			}
+		}
+	}
+	return lapsed
+}
+
+// lapsedTrust returns the names of enabled certificates whose temporary trust
+// ended after the combined bundle was last built, i.e. that are still in it.
+func lapsedTrust(md *Metadata, now time.Time) []string {
+	var names []string
+	for _, info := range md.UserCerts {
+		if info.Disabled || !info.TrustLapsed(now) {
+			continue
+		}
+		if info.TrustUntil.After(md.CombinedBundle.Generated) {
+			names = append(names, info.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,85 @@
+package certstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_Refresh_LapsedTrust(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	proxyPEM := generateTestCert(t, "Debug Proxy CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	proxyPath := filepath.Join(tmpDir, "proxy.pem")
+	if err := os.WriteFile(proxyPath, proxyPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	t.Run("trust until in the past", func(t *testing.T) {
+		err := store.AddCertWithOptions(ctx, proxyPath, "proxy", AddCertOptions{TrustUntil: time.Now().Add(-time.Hour)})
+		if err == nil {
+			t.Error("AddCertWithOptions() should reject a trust expiry in the past")
+		}
+	})
+
+	if err := store.AddCertWithOptions(ctx, proxyPath, "proxy", AddCertOptions{TrustUntil: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("AddCertWithOptions() error = %v", err)
+	}
+	if !combinedBundleContains(t, store, "CN=Debug Proxy CA") {
+		t.Fatal("temporarily trusted certificate should be in the combined bundle")
+	}
+
+	// Still trusted: nothing to do
+	if result, err := store.Refresh(ctx); err != nil || result.Changed() {
+		t.Errorf("Refresh() = %+v, %v, want no changes", result, err)
+	}
+
+	// Let the trust lapse after the bundle was built
+	if err := store.UpdateMetadata(ctx, func(md *Metadata) error {
+		md.CombinedBundle.Generated = time.Now().Add(-2 * time.Hour)
+		for i := range md.UserCerts {
+			if md.UserCerts[i].Name == "proxy" {
+				md.UserCerts[i].TrustUntil = time.Now().Add(-time.Hour)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateMetadata() error = %v", err)
+	}
+
+	result, err := store.Refresh(ctx)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if len(result.LapsedTrust) != 1 || result.LapsedTrust[0] != "proxy" {
+		t.Errorf("LapsedTrust = %v, want [proxy]", result.LapsedTrust)
+	}
+	if combinedBundleContains(t, store, "CN=Debug Proxy CA") {
+		t.Error("lapsed certificate should be dropped from the combined bundle")
+	}
+	if !combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Error("other certificates should stay in the combined bundle")
+	}
+
+	// The file and entry are kept, and the lapse is reported only once
+	if _, err := os.Stat(store.userCertPath("proxy")); err != nil {
+		t.Errorf("lapsed certificate file should be kept: %v", err)
+	}
+	if _, err := store.GetCertInfo("proxy"); err != nil {
+		t.Errorf("GetCertInfo() error = %v", err)
+	}
+	if result, err := store.Refresh(ctx); err != nil || result.Changed() {
+		t.Errorf("second Refresh() = %+v, %v, want no changes", result, err)
+	}
+
+	// Re-adding without a TTL trusts it again indefinitely
+	if err := store.AddCertWithOptions(ctx, proxyPath, "proxy", AddCertOptions{}); err != nil {
+		t.Fatalf("AddCertWithOptions() error = %v", err)
+	}
+	if !combinedBundleContains(t, store, "CN=Debug Proxy CA") {
+		t.Error("re-added certificate should be back in the combined bundle")
+	}
+}
@@ -0,0 +1,210 @@
+package certstore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// retiringName returns the bundle source name of a revision that is being
+// retired after a replace.
+func retiringName(name string) string {
+	return name + " (retiring)"
+}
+
+// retiringCertPath returns the relative path (under certs/) for a replaced
+// revision of a user certificate.
+func retiringCertPath(name string, replaced time.Time) string {
+	return fmt.Sprintf("user/retiring/%s-%s.pem", name, replaced.UTC().Format("20060102T150405Z"))
+}
+
+// RenameCert renames a user certificate. The file and the metadata entry
+// are moved in one transaction and the combined bundle is rebuilt, so
+// its source comments use the new name.
+// Returns ErrCertNotFound if old doesn't exist and ErrCertExists if new does.
+func (s *Store) RenameCert(ctx context.Context, oldName, newName string) error {
+	if !s.IsInitialized() {
+		return &verifierrors.VerifiError{
+			Op:  "rename certificate",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	if err := validateCertName("rename certificate", newName); err != nil {
+		return err
+	}
+
+	// Check context
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	oldPath := s.userCertPath(oldName)
+	newPath := s.userCertPath(newName)
+
+	return s.update(ctx, "rename certificate", func(tx *storeTx) error {
+		md := tx.md
+		index := -1
+		for i, info := range md.UserCerts {
+			switch info.Name {
+			case oldName:
+				index = i
+			case newName:
+				return &verifierrors.VerifiError{
+					Op:   "rename certificate",
+					Path: newName,
+					Err:  verifierrors.ErrCertExists,
+				}
+			}
+		}
+		if index < 0 {
+			return &verifierrors.VerifiError{
+				Op:   "rename certificate",
+				Path: oldName,
+				Err:  verifierrors.ErrCertNotFound,
+			}
+		}
+
+		// A file without a metadata entry would be silently overwritten
+		if _, err := s.fs.Stat(newPath); err == nil {
+			return &verifierrors.VerifiError{
+				Op:   "rename certificate",
+				Path: newPath,
+				Err:  verifierrors.ErrCertExists,
+			}
+		}
+
+		if err := tx.rename(oldPath, newPath); err != nil {
+			return err
+		}
+
+		md.UserCerts[index].Name = newName
+		md.UserCerts[index].Path = "user/" + newName + ".pem"
+
+		return s.RebuildBundle(ctx, md)
+	})
+}
+
+// ReplaceCert replaces the certificate file of an existing user certificate,
+// keeping its labels. The previous version is recorded as a revision. With a
+// positive overlap, the previous version stays in the combined bundle next to
+// its replacement until the overlap has passed; Refresh drops it afterwards.
+// With no overlap it is dropped immediately.
+// Returns ErrCertNotFound if the certificate doesn't exist.
+func (s *Store) ReplaceCert(ctx context.Context, name, certPath string, opts AddCertOptions, overlap time.Duration) error {
+	if !s.IsInitialized() {
+		return &verifierrors.VerifiError{
+			Op:  "replace certificate",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	if overlap < 0 {
+		return &verifierrors.VerifiError{
+			Op:  "replace certificate",
+			Err: fmt.Errorf("overlap must not be negative"),
+		}
+	}
+
+	// Refuse to touch a store written by a newer verifi
+	if err := s.CheckWritable(); err != nil {
+		return err
+	}
+
+	// Check context
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	certs, metas, format, err := s.loadCertFile(certPath, opts)
+	if err != nil {
+		return err
+	}
+
+	destPath := s.userCertPath(name)
+
+	return s.update(ctx, "replace certificate", func(tx *storeTx) error {
+		md := tx.md
+		index := -1
+		for i, info := range md.UserCerts {
+			if info.Name == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return &verifierrors.VerifiError{
+				Op:   "replace certificate",
+				Path: name,
+				Err:  verifierrors.ErrCertNotFound,
+			}
+		}
+		existing := md.UserCerts[index]
+
+		if existing.Fingerprint == metas[0].Fingerprint {
+			return &verifierrors.VerifiError{
+				Op:   "replace certificate",
+				Path: certPath,
+				Err:  fmt.Errorf("certificate is identical to the current version of '%s'", name),
+			}
+		}
+
+		previous, err := s.fs.ReadFile(destPath)
+		if err != nil {
+			return &verifierrors.VerifiError{
+				Op:   "read certificate",
+				Path: destPath,
+				Err:  err,
+			}
+		}
+
+		now := time.Now()
+		revision := CertRevision{
+			Fingerprint: existing.Fingerprint,
+			Subject:     existing.Subject,
+			Expires:     existing.Expires,
+			Added:       existing.Added,
+			Replaced:    now,
+			RetireAt:    now.Add(overlap),
+		}
+
+		// Keep the previous version for the overlap
+		if overlap > 0 {
+			revision.Path = retiringCertPath(name, now)
+			retiringPath := filepath.Join(s.basePath, "certs", revision.Path)
+			if err := s.fs.MkdirAll(filepath.Dir(retiringPath), 0755); err != nil {
+				return &verifierrors.VerifiError{
+					Op:   "create directory",
+					Path: filepath.Dir(retiringPath),
+					Err:  err,
+				}
+			}
+			if err := tx.writeFile(retiringPath, previous); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.writeFile(destPath, EncodeCertsPEM(certs)); err != nil {
+			return err
+		}
+
+		info := newUserCertInfo(name, certs, metas)
+		info.SourceFormat = format
+		info.Tags = existing.Tags
+		info.Note = existing.Note
+		info.Owner = existing.Owner
+		info.Disabled = existing.Disabled
+		info.TrustUntil = existing.TrustUntil
+		info.Revisions = append(existing.Revisions, revision)
+		md.UserCerts[index] = info
+
+		return s.RebuildBundle(ctx, md)
+	})
+}
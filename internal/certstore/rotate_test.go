@@ -0,0 +1,249 @@
+package certstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// newRotationTestStore returns an initialized store with a user certificate
+// named "corp" whose subject is "Corp Root 2025".
+func newRotationTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	certPEM := generateTestCert(t, "Corp Root 2025", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	certPath := filepath.Join(tmpDir, "corp-2025.pem")
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCertWithOptions(ctx, certPath, "corp", AddCertOptions{Tags: []string{"corp"}, Owner: "secops"}); err != nil {
+		t.Fatalf("AddCertWithOptions() error = %v", err)
+	}
+
+	return store, tmpDir
+}
+
+// combinedBundleContains reports whether the combined bundle mentions subject.
+func combinedBundleContains(t *testing.T, store *Store, subject string) bool {
+	t.Helper()
+	data, err := os.ReadFile(store.CombinedBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return strings.Contains(string(data), subject)
+}
+
+func TestStore_RenameCert(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	if err := store.RenameCert(ctx, "corp", "corp-root"); err != nil {
+		t.Fatalf("RenameCert() error = %v", err)
+	}
+
+	if _, err := os.Stat(store.userCertPath("corp")); !os.IsNotExist(err) {
+		t.Errorf("old file should be gone, stat error = %v", err)
+	}
+	if _, err := os.Stat(store.userCertPath("corp-root")); err != nil {
+		t.Errorf("new file missing: %v", err)
+	}
+
+	info, err := store.GetCertInfo("corp-root")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+	if info.Path != "user/corp-root.pem" || info.Owner != "secops" {
+		t.Errorf("renamed entry = %+v", info)
+	}
+	if _, err := store.GetCertInfo("corp"); !errors.Is(err, verifierrors.ErrCertNotFound) {
+		t.Errorf("GetCertInfo(corp) error = %v, want ErrCertNotFound", err)
+	}
+	if !combinedBundleContains(t, store, "# Source: user:corp-root") {
+		t.Error("combined bundle should name the new source")
+	}
+
+	t.Run("target exists", func(t *testing.T) {
+		otherPEM := generateTestCert(t, "Other CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+		otherPath := filepath.Join(tmpDir, "other.pem")
+		if err := os.WriteFile(otherPath, otherPEM, 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if err := store.AddCert(ctx, otherPath, "other", false); err != nil {
+			t.Fatalf("AddCert() error = %v", err)
+		}
+
+		if err := store.RenameCert(ctx, "corp-root", "other"); !errors.Is(err, verifierrors.ErrCertExists) {
+			t.Errorf("RenameCert() error = %v, want ErrCertExists", err)
+		}
+		if _, err := os.Stat(store.userCertPath("corp-root")); err != nil {
+			t.Errorf("file should not move when the rename fails: %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if err := store.RenameCert(ctx, "missing", "new"); !errors.Is(err, verifierrors.ErrCertNotFound) {
+			t.Errorf("RenameCert() error = %v, want ErrCertNotFound", err)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		if err := store.RenameCert(ctx, "corp-root", "../escape"); err == nil {
+			t.Error("RenameCert() should reject path separators")
+		}
+	})
+}
+
+func TestStore_ReplaceCert_Overlap(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	newPEM := generateTestCert(t, "Corp Root 2026", time.Now().Add(-24*time.Hour), time.Now().Add(2*365*24*time.Hour))
+	newPath := filepath.Join(tmpDir, "corp-2026.pem")
+	if err := os.WriteFile(newPath, newPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := store.ReplaceCert(ctx, "corp", newPath, AddCertOptions{}, 30*24*time.Hour); err != nil {
+		t.Fatalf("ReplaceCert() error = %v", err)
+	}
+
+	info, err := store.GetCertInfo("corp")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+	if info.Subject != "CN=Corp Root 2026" || info.Owner != "secops" || len(info.Tags) != 1 {
+		t.Errorf("replaced entry = %+v, want new subject with labels kept", info)
+	}
+	if len(info.Revisions) != 1 {
+		t.Fatalf("Revisions = %+v, want 1", info.Revisions)
+	}
+	rev := info.Revisions[0]
+	if rev.Subject != "CN=Corp Root 2025" || !rev.Active(time.Now()) {
+		t.Errorf("revision = %+v, want active revision of the 2025 root", rev)
+	}
+
+	// Both roots are trusted during the overlap
+	if !combinedBundleContains(t, store, "CN=Corp Root 2025") || !combinedBundleContains(t, store, "CN=Corp Root 2026") {
+		t.Error("combined bundle should contain both roots during the overlap")
+	}
+	if !combinedBundleContains(t, store, "# Source: user:corp (retiring)") {
+		t.Error("combined bundle should label the retiring root")
+	}
+
+	// Nothing to retire yet
+	if result, err := store.Refresh(ctx); err != nil || result.Changed() {
+		t.Errorf("Refresh() = %+v, %v, want no changes", result, err)
+	}
+
+	// Move the retirement date into the past
+	if err := store.UpdateMetadata(ctx, func(md *Metadata) error {
+		md.UserCerts[0].Revisions[0].RetireAt = time.Now().Add(-time.Minute)
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateMetadata() error = %v", err)
+	}
+
+	result, err := store.Refresh(ctx)
+	if err != nil || result.RetiredRevisions != 1 {
+		t.Fatalf("Refresh() = %+v, %v, want 1 retired revision", result, err)
+	}
+	if combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Error("retired root should no longer be in the combined bundle")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "certs", rev.Path)); !os.IsNotExist(err) {
+		t.Errorf("retired revision file should be deleted, stat error = %v", err)
+	}
+
+	info, err = store.GetCertInfo("corp")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+	if len(info.Revisions) != 1 || info.Revisions[0].Path != "" {
+		t.Errorf("revision should stay as history without a file: %+v", info.Revisions)
+	}
+}
+
+func TestStore_ReplaceCert_NoOverlap(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	newPEM := generateTestCert(t, "Corp Root 2026", time.Now().Add(-24*time.Hour), time.Now().Add(2*365*24*time.Hour))
+	newPath := filepath.Join(tmpDir, "corp-2026.pem")
+	if err := os.WriteFile(newPath, newPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	if err := store.ReplaceCert(ctx, "corp", newPath, AddCertOptions{}, 0); err != nil {
+		t.Fatalf("ReplaceCert() error = %v", err)
+	}
+
+	if combinedBundleContains(t, store, "CN=Corp Root 2025") {
+		t.Error("old root should be dropped immediately without an overlap")
+	}
+	info, err := store.GetCertInfo("corp")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+	if len(info.Revisions) != 1 || info.Revisions[0].Path != "" {
+		t.Errorf("Revisions = %+v, want one retired revision", info.Revisions)
+	}
+
+	t.Run("identical certificate", func(t *testing.T) {
+		err := store.ReplaceCert(ctx, "corp", newPath, AddCertOptions{}, 0)
+		if err == nil || !strings.Contains(err.Error(), "identical") {
+			t.Errorf("ReplaceCert() error = %v, want identical certificate error", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		err := store.ReplaceCert(ctx, "missing", newPath, AddCertOptions{}, 0)
+		if !errors.Is(err, verifierrors.ErrCertNotFound) {
+			t.Errorf("ReplaceCert() error = %v, want ErrCertNotFound", err)
+		}
+	})
+}
+
+func TestStore_RemoveCert_DeletesRevisions(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	newPEM := generateTestCert(t, "Corp Root 2026", time.Now().Add(-24*time.Hour), time.Now().Add(2*365*24*time.Hour))
+	newPath := filepath.Join(tmpDir, "corp-2026.pem")
+	if err := os.WriteFile(newPath, newPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.ReplaceCert(ctx, "corp", newPath, AddCertOptions{}, time.Hour); err != nil {
+		t.Fatalf("ReplaceCert() error = %v", err)
+	}
+	info, err := store.GetCertInfo("corp")
+	if err != nil {
+		t.Fatalf("GetCertInfo() error = %v", err)
+	}
+
+	if err := store.RemoveCert(ctx, "corp"); err != nil {
+		t.Fatalf("RemoveCert() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "certs", info.Revisions[0].Path)); !os.IsNotExist(err) {
+		t.Errorf("revision file should be deleted with the certificate, stat error = %v", err)
+	}
+	if combinedBundleContains(t, store, "Corp Root") {
+		t.Error("neither root should remain in the combined bundle")
+	}
+}
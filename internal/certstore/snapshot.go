@@ -0,0 +1,114 @@
+package certstore
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// Snapshot is a consistent view of the store: the metadata, both bundles and
+// the user certificate files, read together under a shared lock so that no
+// transaction is half-applied in it. Files that could not be read are left
+// nil with their error recorded, so a damaged store can still be inspected.
+type Snapshot struct {
+	Taken time.Time
+
+	Metadata    *Metadata
+	MetadataErr error
+
+	MozillaBundle    []byte
+	MozillaBundleErr error
+
+	CombinedBundle    []byte
+	CombinedBundleErr error
+
+	// UserFiles holds the files in certs/user and certs/user/retiring, keyed
+	// by their path relative to certs/ (as in UserCertInfo.Path).
+	UserFiles map[string][]byte
+}
+
+// Snapshot reads the store consistently. An operation interrupted by a crash
+// is recovered first, since its files would otherwise show up half-changed.
+func (s *Store) Snapshot(ctx context.Context) (*Snapshot, error) {
+	if !s.IsInitialized() {
+		return nil, &verifierrors.VerifiError{
+			Op:  "read store",
+			Err: verifierrors.ErrStoreNotInit,
+		}
+	}
+
+	if _, err := s.Recover(ctx); err != nil {
+		return nil, err
+	}
+
+	lock := NewFileLock(s.metadataPath())
+	if err := lock.RLock(ctx); err != nil {
+		return nil, &verifierrors.VerifiError{
+			Op:   "read store",
+			Path: s.metadataPath(),
+			Err:  err,
+		}
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	snap := &Snapshot{
+		Taken:     time.Now(),
+		UserFiles: make(map[string][]byte),
+	}
+	snap.Metadata, snap.MetadataErr = s.readMetadata()
+	snap.MozillaBundle, snap.MozillaBundleErr = s.fs.ReadFile(s.mozillaBundlePath())
+	snap.CombinedBundle, snap.CombinedBundleErr = s.fs.ReadFile(s.CombinedBundlePath())
+
+	for _, dir := range []string{"user", "user/retiring"} {
+		entries, err := s.fs.ReadDir(filepath.Join(s.basePath, "certs", filepath.FromSlash(dir)))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || isTempFile(entry.Name()) {
+				continue
+			}
+			rel := dir + "/" + entry.Name()
+			data, err := s.fs.ReadFile(filepath.Join(s.basePath, "certs", filepath.FromSlash(rel)))
+			if err != nil {
+				continue
+			}
+			snap.UserFiles[rel] = data
+		}
+	}
+
+	return snap, nil
+}
+
+// UserCerts returns the user certificate entries, or nil if the metadata
+// could not be read.
+func (snap *Snapshot) UserCerts() []UserCertInfo {
+	if snap.Metadata == nil {
+		return nil
+	}
+	return snap.Metadata.UserCerts
+}
+
+// HasUserFile reports whether the file at rel (relative to certs/) existed.
+func (snap *Snapshot) HasUserFile(rel string) bool {
+	_, ok := snap.UserFiles[rel]
+	return ok
+}
+
+// UserFileNames returns the paths of the files directly in certs/user whose
+// name ends in suffix, relative to certs/ and sorted.
+func (snap *Snapshot) UserFileNames(suffix string) []string {
+	var names []string
+	for rel := range snap.UserFiles {
+		if path.Dir(rel) == "user" && strings.HasSuffix(rel, suffix) {
+			names = append(names, rel)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,81 @@
+package certstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/princespaghetti/verifi/internal/fetcher"
+)
+
+func TestStore_Snapshot(t *testing.T) {
+	store, tmpDir := newRotationTestStore(t)
+	ctx := context.Background()
+
+	stray := filepath.Join(tmpDir, "certs", "user", "stray.pem")
+	if err := os.WriteFile(stray, []byte("stray"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(stray+".tmp", []byte("partial"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	snap, err := store.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+
+	if snap.MetadataErr != nil || snap.MozillaBundleErr != nil || snap.CombinedBundleErr != nil {
+		t.Fatalf("Snapshot() read errors: %v, %v, %v", snap.MetadataErr, snap.MozillaBundleErr, snap.CombinedBundleErr)
+	}
+	if got := fetcher.ComputeSHA256(snap.CombinedBundle); got != snap.Metadata.CombinedBundle.SHA256 {
+		t.Error("combined bundle should match the metadata it was read with")
+	}
+	if got := fetcher.ComputeSHA256(snap.MozillaBundle); got != snap.Metadata.MozillaBundle.SHA256 {
+		t.Error("Mozilla bundle should match the metadata it was read with")
+	}
+
+	if len(snap.UserCerts()) != 1 {
+		t.Errorf("len(UserCerts()) = %d, want 1", len(snap.UserCerts()))
+	}
+	if !snap.HasUserFile("user/corp.pem") {
+		t.Error("HasUserFile(user/corp.pem) = false, want true")
+	}
+	names := snap.UserFileNames(".pem")
+	if len(names) != 2 || names[0] != "user/corp.pem" || names[1] != "user/stray.pem" {
+		t.Errorf("UserFileNames() = %v, want [user/corp.pem user/stray.pem]", names)
+	}
+}
+
+func TestStore_Snapshot_WaitsForWriter(t *testing.T) {
+	store, _ := newRotationTestStore(t)
+
+	lock, err := store.lockStore(context.Background())
+	if err != nil {
+		t.Fatalf("lockStore() error = %v", err)
+	}
+
+	// A snapshot cannot be taken while a transaction holds the store lock
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := store.Snapshot(ctx); err == nil {
+		t.Error("Snapshot() should not succeed while the store is locked")
+	}
+
+	_ = lock.Unlock()
+	if _, err := store.Snapshot(context.Background()); err != nil {
+		t.Errorf("Snapshot() error = %v after unlock", err)
+	}
+}
+
+func TestStore_Snapshot_NotInitialized(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if _, err := store.Snapshot(context.Background()); err == nil {
+		t.Error("Snapshot() should fail for an uninitialized store")
+	}
+}
@@ -2,6 +2,7 @@ package certstore
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
 	"os/user"
 	"path/filepath"
@@ -131,7 +132,21 @@ func (s *Store) createDirectories() error {
 }
 
 // RebuildBundle rebuilds the combined certificate bundle from Mozilla bundle and user certs.
-// It should be called within an UpdateMetadata callback to ensure proper locking.
+// Callers that change the store run it inside an update transaction, passing
+// tx.md, so it runs under the store lock and sees the metadata about to be
+// written. The combined bundle is not journalled: recovery rebuilds it from
+// the metadata on disk instead.
+//
+// User certificates are selected from metadata: disabled entries, and files in
+// certs/user/ that have no metadata entry, are left out.
+//
+// Every source file is decoded and re-encoded as canonical PEM, so stray text,
+// CRLF line endings or a missing trailing newline in one file cannot corrupt
+// the next certificate. If any file cannot be decoded, the rebuild fails and
+// the existing combined bundle is left untouched. Certificates are deduplicated
+// by fingerprint; those provided by several sources are recorded in
+// BundleInfo.Duplicates. Each certificate is preceded by a comment header
+// naming its sources, subject, fingerprint and expiry.
 func (s *Store) RebuildBundle(ctx context.Context, metadata *Metadata) error {
 	bundlePath := s.CombinedBundlePath()
 	tempPath := bundlePath + ".tmp"
@@ -154,19 +169,30 @@ func (s *Store) RebuildBundle(ctx context.Context, metadata *Metadata) error {
 	}
 
 	// Start with Mozilla bundle
-	combined := mozillaData
+	mozillaCerts, err := decodeBundleCerts(mozillaData, s.mozillaBundlePath())
+	if err != nil {
+		return err
+	}
+	builder := newBundleBuilder()
+	builder.add(mozillaCerts, AnchorSourceMozilla, mozillaData)
 
-	// Append user certs
-	userCerts, err := s.readUserCerts(ctx)
+	// Append enabled user certs, skipping any already in the bundle
+	userFiles, err := s.readUserCertFiles(ctx, metadata.UserCerts)
 	if err != nil {
 		return err
 	}
 
-	// Concatenate user certificates to the bundle
-	for _, certData := range userCerts {
-		combined = append(combined, certData...)
+	for _, file := range userFiles {
+		userCerts, err := decodeBundleCerts(file.Data, file.Path)
+		if err != nil {
+			return err
+		}
+		builder.add(userCerts, "user:"+file.Name, file.Data)
 	}
 
+	generated := time.Now()
+	combined := builder.encode(generated)
+
 	// Write to temp file
 	if err := s.fs.WriteFile(tempPath, combined, 0644); err != nil {
 		return &verifierrors.VerifiError{
@@ -188,15 +214,16 @@ func (s *Store) RebuildBundle(ctx context.Context, metadata *Metadata) error {
 
 	// Update metadata - include sources based on what's in the bundle
 	sources := []string{"mozilla"}
-	if len(userCerts) > 0 {
+	if len(userFiles) > 0 {
 		sources = append(sources, "user")
 	}
 
 	metadata.CombinedBundle = BundleInfo{
-		Generated: time.Now(),
-		SHA256:    fetcher.ComputeSHA256(combined),
-		CertCount: fetcher.CountCertificates(combined),
-		Sources:   sources,
+		Generated:  generated,
+		SHA256:     fetcher.ComputeSHA256(combined),
+		CertCount:  len(builder.entries),
+		Sources:    sources,
+		Duplicates: builder.duplicates(),
 	}
 
 	return nil
@@ -212,9 +239,39 @@ func (s *Store) mozillaBundlePath() string {
 	return filepath.Join(s.basePath, "certs", "bundles", "mozilla-ca-bundle.pem")
 }
 
+// AddCertOptions controls how AddCertWithOptions imports a certificate file.
+type AddCertOptions struct {
+	// Force allows expired certificates to be added.
+	Force bool
+	// CAOnly imports only the CA certificates from a multi-certificate file,
+	// skipping any leaf certificates that are part of the chain.
+	CAOnly bool
+	// Password decrypts PKCS#12 input. It is ignored for other formats.
+	Password string
+	// AllowLeaf permits leaf (server) certificates, which are refused by
+	// default because trusting one rarely does what the user intended.
+	AllowLeaf bool
+	// Tags, Note and Owner label the entry. When an existing entry is
+	// replaced, its labels are kept for any of these left empty.
+	Tags  []string
+	Note  string
+	Owner string
+	// TrustUntil, if set, limits how long the certificate is trusted. After
+	// it passes, the certificate is left out of the combined bundle.
+	TrustUntil time.Time
+}
+
 // AddCert adds a certificate to the user certificate store.
 // The certificate is validated before being added. If force is true, expired certificates are allowed.
 func (s *Store) AddCert(ctx context.Context, certPath, name string, force bool) error {
+	return s.AddCertWithOptions(ctx, certPath, name, AddCertOptions{Force: force})
+}
+
+// AddCertWithOptions adds a certificate file to the user certificate store.
+// The input format (PEM, DER, base64 DER, PKCS#7 or PKCS#12) is detected
+// automatically. Every certificate in the file is validated, and only the
+// validated certificates are re-encoded as PEM and written to certs/user/<name>.pem.
+func (s *Store) AddCertWithOptions(ctx context.Context, certPath, name string, opts AddCertOptions) error {
 	// Check if store is initialized
 	if !s.IsInitialized() {
 		return &verifierrors.VerifiError{
@@ -223,11 +280,20 @@ func (s *Store) AddCert(ctx context.Context, certPath, name string, force bool)
 		}
 	}
 
+	// Refuse to touch a store written by a newer verifi
+	if err := s.CheckWritable(); err != nil {
+		return err
+	}
+
 	// Validate certificate name (no path separators allowed)
-	if strings.Contains(name, "/") || strings.Contains(name, "\\") || strings.Contains(name, "..") {
+	if err := validateCertName("add certificate", name); err != nil {
+		return err
+	}
+
+	if !opts.TrustUntil.IsZero() && !opts.TrustUntil.After(time.Now()) {
 		return &verifierrors.VerifiError{
 			Op:  "add certificate",
-			Err: fmt.Errorf("certificate name must not contain path separators or '..'"),
+			Err: fmt.Errorf("trust expiry %s is in the past", opts.TrustUntil.Format(time.RFC3339)),
 		}
 	}
 
@@ -238,34 +304,136 @@ func (s *Store) AddCert(ctx context.Context, certPath, name string, force bool)
 	default:
 	}
 
+	certs, metas, format, err := s.loadCertFile(certPath, opts)
+	if err != nil {
+		return err
+	}
+
+	// Check context again before writing
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	info := newUserCertInfo(name, certs, metas)
+	info.SourceFormat = format
+	info.Tags = normalizeTags(opts.Tags)
+	info.Note = strings.TrimSpace(opts.Note)
+	info.Owner = strings.TrimSpace(opts.Owner)
+	info.TrustUntil = opts.TrustUntil
+
+	// Write the file, record it and rebuild the bundle in one transaction
+	return s.update(ctx, "add certificate", func(tx *storeTx) error {
+		if err := tx.writeFile(s.userCertPath(name), EncodeCertsPEM(certs)); err != nil {
+			return err
+		}
+
+		replaced := false
+		for i, existing := range tx.md.UserCerts {
+			if existing.Name == name {
+				// Replace existing certificate, keeping labels that were not given,
+				// whether it is disabled and its revisions
+				info.Disabled = existing.Disabled
+				info.Revisions = existing.Revisions
+				if info.Tags == nil {
+					info.Tags = existing.Tags
+				}
+				if info.Note == "" {
+					info.Note = existing.Note
+				}
+				if info.Owner == "" {
+					info.Owner = existing.Owner
+				}
+				tx.md.UserCerts[i] = info
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			tx.md.UserCerts = append(tx.md.UserCerts, info)
+		}
+
+		return s.RebuildBundle(ctx, tx.md)
+	})
+}
+
+// validateCertName checks that a certificate name is usable as a file name.
+func validateCertName(op, name string) error {
+	if name == "" {
+		return &verifierrors.VerifiError{
+			Op:  op,
+			Err: fmt.Errorf("certificate name must not be empty"),
+		}
+	}
+	if strings.Contains(name, "/") || strings.Contains(name, "\\") || strings.Contains(name, "..") {
+		return &verifierrors.VerifiError{
+			Op:  op,
+			Err: fmt.Errorf("certificate name must not contain path separators or '..'"),
+		}
+	}
+	return nil
+}
+
+// loadCertFile reads a certificate file in any supported format, validates
+// every certificate in it and applies the CAOnly and AllowLeaf options.
+func (s *Store) loadCertFile(certPath string, opts AddCertOptions) ([]*x509.Certificate, []*CertMetadata, CertFormat, error) {
 	// Read certificate file
 	certData, err := s.fs.ReadFile(certPath)
 	if err != nil {
-		return &verifierrors.VerifiError{
+		return nil, nil, "", &verifierrors.VerifiError{
 			Op:   "read certificate",
 			Path: certPath,
 			Err:  err,
 		}
 	}
 
-	// Validate certificate
-	_, metadata, err := ValidateCert(certData, force)
+	// Convert to PEM from whatever format the file is in
+	pemData, format, err := ConvertToPEM(certData, opts.Password)
 	if err != nil {
-		return err
+		return nil, nil, "", err
 	}
 
-	// Check context again before writing
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
+	// Validate every certificate in the file
+	certs, metas, err := ValidateCertChain(pemData, opts.Force)
+	if err != nil {
+		return nil, nil, "", err
 	}
 
-	// Write certificate to user directory with atomic rename
-	destPath := s.userCertPath(name)
-	tempPath := destPath + ".tmp"
+	// Optionally keep only the CA certificates from the chain
+	if opts.CAOnly {
+		certs, metas = selectCACerts(certs, metas)
+		if len(certs) == 0 {
+			return nil, nil, "", &verifierrors.VerifiError{
+				Op:   "add certificate",
+				Path: certPath,
+				Err:  verifierrors.ErrNoCACerts,
+			}
+		}
+	}
 
-	if err := s.fs.WriteFile(tempPath, certData, 0644); err != nil {
+	// Refuse leaf certificates unless explicitly allowed
+	if !opts.AllowLeaf {
+		for _, meta := range metas {
+			if meta.Role == RoleLeaf {
+				return nil, nil, "", &verifierrors.VerifiError{
+					Op:   "add certificate",
+					Path: certPath,
+					Err:  fmt.Errorf("%w: %s", verifierrors.ErrLeafCert, meta.Subject),
+				}
+			}
+		}
+	}
+
+	return certs, metas, format, nil
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// into place.
+func (s *Store) writeFileAtomic(path string, data []byte) error {
+	tempPath := path + ".tmp"
+
+	if err := s.fs.WriteFile(tempPath, data, 0644); err != nil {
 		return &verifierrors.VerifiError{
 			Op:   "write certificate",
 			Path: tempPath,
@@ -273,66 +441,61 @@ func (s *Store) AddCert(ctx context.Context, certPath, name string, force bool)
 		}
 	}
 
-	if err := s.fs.Rename(tempPath, destPath); err != nil {
+	if err := s.fs.Rename(tempPath, path); err != nil {
 		_ = s.fs.Remove(tempPath)
 		return &verifierrors.VerifiError{
 			Op:   "rename certificate",
-			Path: destPath,
+			Path: path,
 			Err:  err,
 		}
 	}
 
-	// Update metadata with file locking
-	updateErr := s.UpdateMetadata(ctx, func(md *Metadata) error {
-		// Check if certificate with this name already exists
-		for i, existing := range md.UserCerts {
-			if existing.Name == name {
-				// Replace existing certificate
-				md.UserCerts[i] = UserCertInfo{
-					Name:        name,
-					Path:        "user/" + name + ".pem",
-					Added:       time.Now(),
-					Fingerprint: metadata.Fingerprint,
-					Subject:     metadata.Subject,
-					Expires:     metadata.Expires,
-				}
-				return nil
-			}
-		}
-
-		// Add new certificate
-		md.UserCerts = append(md.UserCerts, UserCertInfo{
-			Name:        name,
-			Path:        "user/" + name + ".pem",
-			Added:       time.Now(),
-			Fingerprint: metadata.Fingerprint,
-			Subject:     metadata.Subject,
-			Expires:     metadata.Expires,
-		})
-
-		return nil
-	})
+	return nil
+}
 
-	if updateErr != nil {
-		// Rollback: remove the certificate file
-		_ = s.fs.Remove(destPath)
-		return updateErr
+// selectCACerts filters a validated chain down to its CA certificates.
+func selectCACerts(certs []*x509.Certificate, metas []*CertMetadata) ([]*x509.Certificate, []*CertMetadata) {
+	var caCerts []*x509.Certificate
+	var caMetas []*CertMetadata
+	for i, meta := range metas {
+		if meta.Role != RoleLeaf {
+			caCerts = append(caCerts, certs[i])
+			caMetas = append(caMetas, meta)
+		}
 	}
+	return caCerts, caMetas
+}
 
-	// Rebuild the combined bundle with the new certificate
-	// We need to do this outside the UpdateMetadata function to avoid nesting locks
-	rebuildErr := s.UpdateMetadata(ctx, func(md *Metadata) error {
-		return s.RebuildBundle(ctx, md)
-	})
-
-	if rebuildErr != nil {
-		return &verifierrors.VerifiError{
-			Op:  "rebuild bundle after adding certificate",
-			Err: rebuildErr,
+// newUserCertInfo builds the metadata entry for a user certificate file.
+// certs and metas must contain at least one entry.
+func newUserCertInfo(name string, certs []*x509.Certificate, metas []*CertMetadata) UserCertInfo {
+	info := UserCertInfo{
+		Name:        name,
+		Path:        "user/" + name + ".pem",
+		Added:       time.Now(),
+		Fingerprint: metas[0].Fingerprint,
+		Subject:     metas[0].Subject,
+		Expires:     metas[0].Expires,
+		Role:        metas[0].Role,
+	}
+	applyCertDetails(&info, certs[0])
+
+	if len(metas) > 1 {
+		for _, meta := range metas {
+			if meta.Expires.Before(info.Expires) {
+				info.Expires = meta.Expires
+			}
+			info.Certificates = append(info.Certificates, CertSummary{
+				Subject:     meta.Subject,
+				Fingerprint: meta.Fingerprint,
+				Expires:     meta.Expires,
+				IsCA:        meta.IsCA,
+				Role:        meta.Role,
+			})
 		}
 	}
 
-	return nil
+	return info
 }
 
 // ListCerts returns the list of user certificates from metadata.
@@ -411,17 +574,27 @@ func (s *Store) RemoveCert(ctx context.Context, name string) error {
 	default:
 	}
 
-	// Update metadata with file locking
-	updateErr := s.UpdateMetadata(ctx, func(md *Metadata) error {
+	// Remove the entry and its files and rebuild the bundle in one transaction
+	return s.update(ctx, "remove certificate", func(tx *storeTx) error {
 		// Find and remove the certificate from metadata
 		found := false
-		newCerts := make([]UserCertInfo, 0, len(md.UserCerts))
-		for _, cert := range md.UserCerts {
-			if cert.Name == name {
-				found = true
+		newCerts := make([]UserCertInfo, 0, len(tx.md.UserCerts))
+		for _, cert := range tx.md.UserCerts {
+			if cert.Name != name {
+				newCerts = append(newCerts, cert)
 				continue
 			}
-			newCerts = append(newCerts, cert)
+			found = true
+			if err := tx.remove(s.userCertPath(name)); err != nil {
+				return err
+			}
+			for _, rev := range cert.Revisions {
+				if rev.Path != "" {
+					if err := tx.remove(filepath.Join(s.basePath, "certs", rev.Path)); err != nil {
+						return err
+					}
+				}
+			}
 		}
 
 		if !found {
@@ -432,39 +605,36 @@ func (s *Store) RemoveCert(ctx context.Context, name string) error {
 			}
 		}
 
-		md.UserCerts = newCerts
-		return nil
+		tx.md.UserCerts = newCerts
+		return s.RebuildBundle(ctx, tx.md)
 	})
+}
 
-	if updateErr != nil {
-		return updateErr
-	}
-
-	// Remove the physical certificate file
-	certPath := s.userCertPath(name)
-	_ = s.fs.Remove(certPath) // Ignore error - file may not exist, which is okay
+// DisableCert excludes a user certificate from the combined bundle without
+// deleting its file or metadata. The combined bundle is rebuilt.
+// Returns ErrCertNotFound if the certificate doesn't exist.
+func (s *Store) DisableCert(ctx context.Context, name string) error {
+	return s.setCertDisabled(ctx, name, true)
+}
 
-	// Rebuild the combined bundle
-	rebuildErr := s.UpdateMetadata(ctx, func(md *Metadata) error {
-		return s.RebuildBundle(ctx, md)
-	})
+// EnableCert adds a disabled user certificate back to the combined bundle.
+// The combined bundle is rebuilt.
+// Returns ErrCertNotFound if the certificate doesn't exist.
+func (s *Store) EnableCert(ctx context.Context, name string) error {
+	return s.setCertDisabled(ctx, name, false)
+}
 
-	if rebuildErr != nil {
-		return &verifierrors.VerifiError{
-			Op:  "rebuild bundle after removing certificate",
-			Err: rebuildErr,
-		}
+// setCertDisabled updates the disabled flag and rebuilds the combined bundle
+// under a single lock, so the flag is only persisted if the rebuild succeeds.
+func (s *Store) setCertDisabled(ctx context.Context, name string, disabled bool) error {
+	op := "enable certificate"
+	if disabled {
+		op = "disable certificate"
 	}
 
-	return nil
-}
-
-// ResetMozillaBundle resets the Mozilla CA bundle to the embedded version.
-// The combined bundle is rebuilt after the reset.
-func (s *Store) ResetMozillaBundle(ctx context.Context) error {
 	if !s.IsInitialized() {
 		return &verifierrors.VerifiError{
-			Op:  "reset mozilla bundle",
+			Op:  op,
 			Err: verifierrors.ErrStoreNotInit,
 		}
 	}
@@ -476,48 +646,75 @@ func (s *Store) ResetMozillaBundle(ctx context.Context) error {
 	default:
 	}
 
-	// Get embedded bundle
-	embeddedBundle := fetcher.GetEmbeddedBundle()
+	return s.update(ctx, op, func(tx *storeTx) error {
+		found := false
+		for i := range tx.md.UserCerts {
+			if tx.md.UserCerts[i].Name == name {
+				tx.md.UserCerts[i].Disabled = disabled
+				found = true
+				break
+			}
+		}
 
-	// Write to temp file then atomic rename
-	mozillaPath := s.mozillaBundlePath()
-	tempPath := mozillaPath + ".tmp"
+		if !found {
+			return &verifierrors.VerifiError{
+				Op:   op,
+				Path: name,
+				Err:  verifierrors.ErrCertNotFound,
+			}
+		}
+
+		return s.RebuildBundle(ctx, tx.md)
+	})
+}
+
+// ResetMozillaBundle resets the Mozilla CA bundle to the embedded version.
+// The combined bundle is rebuilt after the reset.
+func (s *Store) ResetMozillaBundle(ctx context.Context) error {
+	embeddedBundle := fetcher.GetEmbeddedBundle()
+	return s.UpdateMozillaBundle(ctx, embeddedBundle, BundleInfo{
+		Generated: time.Now(),
+		SHA256:    fetcher.ComputeSHA256(embeddedBundle),
+		CertCount: fetcher.CountCertificates(embeddedBundle),
+		Source:    "embedded",
+		Version:   "", // No version for embedded bundle
+	})
+}
 
-	if err := s.fs.WriteFile(tempPath, embeddedBundle, 0644); err != nil {
+// UpdateMozillaBundle replaces the Mozilla CA bundle with data, records info
+// as its metadata and rebuilds the combined bundle, in one transaction.
+func (s *Store) UpdateMozillaBundle(ctx context.Context, data []byte, info BundleInfo) error {
+	if !s.IsInitialized() {
 		return &verifierrors.VerifiError{
-			Op:   "write mozilla bundle temp file",
-			Path: tempPath,
-			Err:  err,
+			Op:  "update mozilla bundle",
+			Err: verifierrors.ErrStoreNotInit,
 		}
 	}
 
-	if err := s.fs.Rename(tempPath, mozillaPath); err != nil {
-		_ = s.fs.Remove(tempPath)
-		return &verifierrors.VerifiError{
-			Op:   "rename mozilla bundle",
-			Path: mozillaPath,
-			Err:  err,
-		}
+	// Check context
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
 	}
 
-	// Update metadata with locking
-	updateErr := s.UpdateMetadata(ctx, func(md *Metadata) error {
-		certCount := fetcher.CountCertificates(embeddedBundle)
-		md.MozillaBundle = BundleInfo{
-			Generated: time.Now(),
-			SHA256:    fetcher.ComputeSHA256(embeddedBundle),
-			CertCount: certCount,
-			Source:    "embedded",
-			Version:   "", // No version for embedded bundle
+	return s.update(ctx, "update mozilla bundle", func(tx *storeTx) error {
+		if err := tx.writeFile(s.mozillaBundlePath(), data); err != nil {
+			return err
 		}
-
-		// Rebuild combined bundle with reset Mozilla bundle
-		return s.RebuildBundle(ctx, md)
+		tx.md.MozillaBundle = info
+		return s.RebuildBundle(ctx, tx.md)
 	})
+}
 
-	if updateErr != nil {
-		return updateErr
+// SetBundleMirrors stores the ordered list of URLs that bundle updates
+// download from when none are given. An empty list clears it.
+func (s *Store) SetBundleMirrors(ctx context.Context, urls []string) error {
+	if len(urls) == 0 {
+		urls = nil
 	}
-
-	return nil
+	return s.update(ctx, "set bundle mirrors", func(tx *storeTx) error {
+		tx.md.BundleMirrors = urls
+		return nil
+	})
 }
@@ -751,3 +751,115 @@ func TestStore_ResetMozillaBundle_NotInitialized(t *testing.T) {
 		t.Errorf("Expected ErrStoreNotInit, got: %v", err)
 	}
 }
+
+func TestStore_DisableEnableCert(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+
+	certPEM := generateTestCert(t, "Incident CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	certPath := filepath.Join(tmpDir, "incident.pem")
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		t.Fatalf("Failed to write test cert: %v", err)
+	}
+	if err := store.AddCertWithOptions(ctx, certPath, "incident", AddCertOptions{Tags: []string{"corp"}}); err != nil {
+		t.Fatalf("AddCertWithOptions() failed: %v", err)
+	}
+
+	bundleHas := func() bool {
+		data, err := os.ReadFile(store.CombinedBundlePath())
+		if err != nil {
+			t.Fatalf("Failed to read bundle: %v", err)
+		}
+		return strings.Contains(string(data), "CN=Incident CA")
+	}
+	if !bundleHas() {
+		t.Fatal("combined bundle should contain the certificate after add")
+	}
+
+	if err := store.DisableCert(ctx, "incident"); err != nil {
+		t.Fatalf("DisableCert() failed: %v", err)
+	}
+	if bundleHas() {
+		t.Error("combined bundle should not contain a disabled certificate")
+	}
+	if _, err := os.Stat(store.userCertPath("incident")); err != nil {
+		t.Errorf("disabled certificate file should be kept: %v", err)
+	}
+	info, err := store.GetCertInfo("incident")
+	if err != nil {
+		t.Fatalf("GetCertInfo() failed: %v", err)
+	}
+	if !info.Disabled || len(info.Tags) != 1 {
+		t.Errorf("metadata after disable = %+v, want disabled with tags kept", info)
+	}
+	metadata, err := store.readMetadata()
+	if err != nil {
+		t.Fatalf("readMetadata() failed: %v", err)
+	}
+	if strings.Contains(strings.Join(metadata.CombinedBundle.Sources, ","), "user") {
+		t.Errorf("Sources = %v, want no user source while disabled", metadata.CombinedBundle.Sources)
+	}
+	if _, err := store.RootPool(ctx, RootsUser); err == nil {
+		t.Error("RootPool(user) should have no certificates while the only one is disabled")
+	}
+
+	// Re-adding keeps the certificate disabled
+	if err := store.AddCert(ctx, certPath, "incident", false); err != nil {
+		t.Fatalf("AddCert() failed: %v", err)
+	}
+	if bundleHas() {
+		t.Error("re-adding should not re-enable a disabled certificate")
+	}
+
+	if err := store.EnableCert(ctx, "incident"); err != nil {
+		t.Fatalf("EnableCert() failed: %v", err)
+	}
+	if !bundleHas() {
+		t.Error("combined bundle should contain the certificate after enable")
+	}
+
+	if err := store.DisableCert(ctx, "missing"); !errors.Is(err, verifierrors.ErrCertNotFound) {
+		t.Errorf("DisableCert(missing) error = %v, want ErrCertNotFound", err)
+	}
+}
+
+func TestStore_SetBundleMirrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() failed: %v", err)
+	}
+
+	mirrors := []string{"https://a.example.com/cacert.pem", "https://b.example.com/cacert.pem"}
+	if err := store.SetBundleMirrors(ctx, mirrors); err != nil {
+		t.Fatalf("SetBundleMirrors() failed: %v", err)
+	}
+	metadata, err := store.GetMetadata()
+	if err != nil {
+		t.Fatalf("GetMetadata() failed: %v", err)
+	}
+	if strings.Join(metadata.BundleMirrors, " ") != strings.Join(mirrors, " ") {
+		t.Errorf("BundleMirrors = %v, want %v", metadata.BundleMirrors, mirrors)
+	}
+
+	// An empty list clears them
+	if err := store.SetBundleMirrors(ctx, []string{}); err != nil {
+		t.Fatalf("SetBundleMirrors() failed: %v", err)
+	}
+	if metadata, err := store.GetMetadata(); err != nil || metadata.BundleMirrors != nil {
+		t.Errorf("BundleMirrors = %v, %v, want nil", metadata.BundleMirrors, err)
+	}
+}
@@ -0,0 +1,312 @@
+package certstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+	"github.com/princespaghetti/verifi/internal/fetcher"
+)
+
+// Trust anchor sources reported by VerifyChain.
+const (
+	AnchorSourceMozilla = "mozilla"
+	AnchorSourceUnknown = "unknown"
+)
+
+// VerifyResult contains the outcome of a successful chain verification.
+type VerifyResult struct {
+	// Chains are the verified chains, leaf first and trust anchor last.
+	Chains [][]*x509.Certificate
+	// Anchor is the certificate from the combined bundle that validated the first chain.
+	Anchor *x509.Certificate
+	// AnchorSource is "mozilla", "user:<name>" or "unknown".
+	AnchorSource string
+}
+
+// Root sources that VerifyChain can verify against.
+const (
+	RootsAll     = "all"     // Combined bundle (Mozilla + user certificates)
+	RootsMozilla = "mozilla" // Mozilla CA bundle only
+	RootsUser    = "user"    // User certificates only
+)
+
+// Root sources that verifi's own downloads can trust (see DownloadPool).
+const (
+	DownloadRootsCombined = "combined" // Combined bundle, as tools using env.sh trust
+	DownloadRootsEmbedded = "embedded" // Embedded Mozilla bundle plus user certificates
+	DownloadRootsSystem   = "system"   // Operating system roots
+)
+
+// Verification failure reasons reported by VerifyFailureReason.
+const (
+	FailureUnknownAuthority  = "unknown_authority"
+	FailureExpired           = "expired"
+	FailureNameMismatch      = "name_mismatch"
+	FailureIncompatibleUsage = "incompatible_usage"
+	FailureOther             = "other"
+)
+
+// VerifyOptions configures chain verification.
+type VerifyOptions struct {
+	// DNSName, if set, must match the leaf certificate.
+	DNSName string
+	// Roots selects the trust anchors: RootsAll (default), RootsMozilla or RootsUser.
+	Roots string
+}
+
+// CombinedPool builds a certificate pool from the combined bundle, which is
+// exactly what tools using env.sh will trust.
+func (s *Store) CombinedPool() (*x509.CertPool, error) {
+	return s.poolFromFile(s.CombinedBundlePath())
+}
+
+// RootPool builds a certificate pool for the given root source.
+func (s *Store) RootPool(ctx context.Context, roots string) (*x509.CertPool, error) {
+	switch roots {
+	case "", RootsAll:
+		return s.CombinedPool()
+	case RootsMozilla:
+		return s.poolFromFile(s.mozillaBundlePath())
+	case RootsUser:
+		userCerts, err := s.readUserCerts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		loaded := false
+		for _, data := range userCerts {
+			if pool.AppendCertsFromPEM(data) {
+				loaded = true
+			}
+		}
+		if !loaded {
+			return nil, &verifierrors.VerifiError{
+				Op:  "load user certificates",
+				Err: fmt.Errorf("no user certificates found"),
+			}
+		}
+		return pool, nil
+	default:
+		return nil, &verifierrors.VerifiError{
+			Op:  "select roots",
+			Err: fmt.Errorf("unknown root source %q (expected %s, %s or %s)", roots, RootsAll, RootsMozilla, RootsUser),
+		}
+	}
+}
+
+// DownloadPool builds the roots verifi's own HTTPS downloads trust, so they
+// work behind the same TLS-intercepting proxies as the tools it configures.
+// DownloadRootsEmbedded leaves out the downloaded Mozilla bundle, so that a
+// tampered bundle cannot vouch for the server of its replacement. For
+// DownloadRootsSystem the pool is nil, meaning the system roots.
+func (s *Store) DownloadPool(ctx context.Context, roots string) (*x509.CertPool, error) {
+	switch roots {
+	case "", DownloadRootsCombined:
+		return s.CombinedPool()
+	case DownloadRootsEmbedded:
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(fetcher.GetEmbeddedBundle()) {
+			return nil, &verifierrors.VerifiError{
+				Op:  "load embedded bundle",
+				Err: verifierrors.ErrNoCACerts,
+			}
+		}
+		userCerts, err := s.readUserCerts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, data := range userCerts {
+			pool.AppendCertsFromPEM(data)
+		}
+		return pool, nil
+	case DownloadRootsSystem:
+		return nil, nil
+	default:
+		return nil, &verifierrors.VerifiError{
+			Op:  "select roots",
+			Err: fmt.Errorf("unknown root source %q (expected %s, %s or %s)", roots, DownloadRootsCombined, DownloadRootsEmbedded, DownloadRootsSystem),
+		}
+	}
+}
+
+// poolFromFile builds a certificate pool from a PEM bundle file.
+func (s *Store) poolFromFile(path string) (*x509.CertPool, error) {
+	data, err := s.fs.ReadFile(path)
+	if err != nil {
+		return nil, &verifierrors.VerifiError{
+			Op:   "read bundle",
+			Path: path,
+			Err:  err,
+		}
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, &verifierrors.VerifiError{
+			Op:   "load bundle",
+			Path: path,
+			Err:  fmt.Errorf("no certificates found"),
+		}
+	}
+
+	return pool, nil
+}
+
+// VerifyChain verifies a chain (leaf first, as presented in a TLS handshake
+// or stored in a chain file) against the store. Certificates after the leaf
+// are used as intermediates. Verification failures wrap
+// ErrVerificationFailed together with the underlying x509 error; use
+// VerifyFailureReason to classify them.
+func (s *Store) VerifyChain(ctx context.Context, chain []*x509.Certificate, opts VerifyOptions) (*VerifyResult, error) {
+	if len(chain) == 0 {
+		return nil, &verifierrors.VerifiError{
+			Op:  "verify chain",
+			Err: fmt.Errorf("%w: no certificates to verify", verifierrors.ErrVerificationFailed),
+		}
+	}
+
+	roots, err := s.RootPool(ctx, opts.Roots)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       opts.DNSName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.Now(),
+	})
+	if err != nil {
+		return nil, &verifierrors.VerifiError{
+			Op:  "verify chain",
+			Err: fmt.Errorf("%w: %w", verifierrors.ErrVerificationFailed, err),
+		}
+	}
+
+	anchor := chains[0][len(chains[0])-1]
+	return &VerifyResult{
+		Chains:       chains,
+		Anchor:       anchor,
+		AnchorSource: s.AnchorSource(anchor),
+	}, nil
+}
+
+// VerifyFailureReason classifies a VerifyChain error into one of the
+// Failure* constants.
+func VerifyFailureReason(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return FailureUnknownAuthority
+	case errors.As(err, &hostname):
+		return FailureNameMismatch
+	case errors.As(err, &invalid):
+		switch invalid.Reason {
+		case x509.Expired:
+			return FailureExpired
+		case x509.IncompatibleUsage:
+			return FailureIncompatibleUsage
+		}
+	}
+	return FailureOther
+}
+
+// AnchorSource reports where a trusted certificate comes from: "user:<name>"
+// for an enabled user certificate, "mozilla" for the Mozilla bundle, or
+// "unknown". User certificates take precedence when a certificate is in both.
+func (s *Store) AnchorSource(cert *x509.Certificate) string {
+	fingerprint := Fingerprint(cert)
+
+	if metadata, err := s.readMetadata(); err == nil {
+		for _, info := range metadata.UserCerts {
+			if info.Disabled || info.TrustLapsed(time.Now()) {
+				continue
+			}
+			if info.Fingerprint == fingerprint {
+				return "user:" + info.Name
+			}
+			for _, c := range info.Certificates {
+				if c.Fingerprint == fingerprint {
+					return "user:" + info.Name
+				}
+			}
+			for _, rev := range info.Revisions {
+				if rev.Active(time.Now()) && rev.Fingerprint == fingerprint {
+					return "user:" + retiringName(info.Name)
+				}
+			}
+		}
+	}
+
+	if data, err := s.fs.ReadFile(s.mozillaBundlePath()); err == nil {
+		remaining := data
+		for {
+			block, rest := pem.Decode(remaining)
+			if block == nil {
+				break
+			}
+			remaining = rest
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			if mozCert, err := x509.ParseCertificate(block.Bytes); err == nil && mozCert.Equal(cert) {
+				return AnchorSourceMozilla
+			}
+		}
+	}
+
+	return AnchorSourceUnknown
+}
+
+// MissingIssuers returns the intermediates in certs whose issuer is neither
+// in certs itself nor in the combined bundle (Mozilla or user certificates).
+// Tools cannot build a chain to a trusted root through such a certificate.
+func (s *Store) MissingIssuers(certs []*x509.Certificate) ([]*x509.Certificate, error) {
+	data, err := s.fs.ReadFile(s.CombinedBundlePath())
+	if err != nil {
+		return nil, &verifierrors.VerifiError{
+			Op:   "read combined bundle",
+			Path: s.CombinedBundlePath(),
+			Err:  err,
+		}
+	}
+	trusted, err := decodeBundleCerts(data, s.CombinedBundlePath())
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := append(append([]*x509.Certificate{}, certs...), trusted...)
+
+	var missing []*x509.Certificate
+	for _, cert := range certs {
+		if ClassifyCert(cert) != RoleIntermediate {
+			continue
+		}
+		found := false
+		for _, candidate := range candidates {
+			if bytes.Equal(candidate.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(candidate) == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, cert)
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,303 @@
+package certstore
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+func TestStore_VerifyChain(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	chain := []*x509.Certificate{server.Certificate()}
+
+	// Not trusted yet
+	_, err = store.VerifyChain(ctx, chain, VerifyOptions{DNSName: "127.0.0.1"})
+	if !errors.Is(err, verifierrors.ErrVerificationFailed) {
+		t.Fatalf("VerifyChain() error = %v, want ErrVerificationFailed", err)
+	}
+	if got := VerifyFailureReason(err); got != FailureUnknownAuthority {
+		t.Errorf("VerifyFailureReason() = %q, want %q", got, FailureUnknownAuthority)
+	}
+
+	// Trust the server's certificate as a user cert
+	certPath := filepath.Join(tmpDir, "server.pem")
+	if err := os.WriteFile(certPath, EncodeCertsPEM(chain), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, certPath, "test-server", false); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+
+	result, err := store.VerifyChain(ctx, chain, VerifyOptions{DNSName: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("VerifyChain() error = %v", err)
+	}
+	if !result.Anchor.Equal(server.Certificate()) {
+		t.Errorf("Anchor = %s, want server certificate", result.Anchor.Subject)
+	}
+	if result.AnchorSource != "user:test-server" {
+		t.Errorf("AnchorSource = %q, want %q", result.AnchorSource, "user:test-server")
+	}
+
+	// Wrong host name
+	_, err = store.VerifyChain(ctx, chain, VerifyOptions{DNSName: "other.invalid"})
+	if !errors.Is(err, verifierrors.ErrVerificationFailed) {
+		t.Errorf("VerifyChain() wrong host error = %v, want ErrVerificationFailed", err)
+	}
+	if got := VerifyFailureReason(err); got != FailureNameMismatch {
+		t.Errorf("VerifyFailureReason() = %q, want %q", got, FailureNameMismatch)
+	}
+
+	// Empty chain
+	if _, err := store.VerifyChain(ctx, nil, VerifyOptions{}); !errors.Is(err, verifierrors.ErrVerificationFailed) {
+		t.Errorf("VerifyChain() empty chain error = %v, want ErrVerificationFailed", err)
+	}
+}
+
+func TestStore_AnchorSource_Mozilla(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if err := store.Init(context.Background(), false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	data, err := os.ReadFile(store.mozillaBundlePath())
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("Mozilla bundle contains no PEM blocks")
+	}
+	mozCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+
+	if got := store.AnchorSource(mozCert); got != AnchorSourceMozilla {
+		t.Errorf("AnchorSource() = %q, want %q", got, AnchorSourceMozilla)
+	}
+
+	certPEM := generateTestCert(t, "Unrelated CA", mozCert.NotBefore, mozCert.NotAfter)
+	block, _ = pem.Decode(certPEM)
+	unrelated, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	if got := store.AnchorSource(unrelated); got != AnchorSourceUnknown {
+		t.Errorf("AnchorSource() = %q, want %q", got, AnchorSourceUnknown)
+	}
+}
+
+func TestStore_VerifyChain_Roots(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	// No user certificates yet
+	if _, err := store.RootPool(ctx, RootsUser); err == nil {
+		t.Error("RootPool(user) with no user certs should fail")
+	}
+
+	leafPEM, caPEM := generateTestLeafCert(t, "internal.corp.com")
+	caPath := filepath.Join(tmpDir, "ca.pem")
+	if err := os.WriteFile(caPath, caPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, caPath, "corp-ca", false); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+
+	chain, _, err := ValidateCertChain(leafPEM, false)
+	if err != nil {
+		t.Fatalf("ValidateCertChain() error = %v", err)
+	}
+
+	tests := []struct {
+		roots      string
+		wantErr    bool
+		wantReason string
+	}{
+		{roots: RootsAll},
+		{roots: RootsUser},
+		{roots: RootsMozilla, wantErr: true, wantReason: FailureUnknownAuthority},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.roots, func(t *testing.T) {
+			result, err := store.VerifyChain(ctx, chain, VerifyOptions{DNSName: "internal.corp.com", Roots: tt.roots})
+			if tt.wantErr {
+				if got := VerifyFailureReason(err); got != tt.wantReason {
+					t.Errorf("VerifyFailureReason() = %q, want %q (err = %v)", got, tt.wantReason, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyChain() error = %v", err)
+			}
+			if len(result.Chains) == 0 || len(result.Chains[0]) != 2 {
+				t.Errorf("VerifyChain() chains = %v, want one leaf+CA path", result.Chains)
+			}
+			if result.AnchorSource != "user:corp-ca" {
+				t.Errorf("AnchorSource = %q, want %q", result.AnchorSource, "user:corp-ca")
+			}
+		})
+	}
+
+	if _, err := store.VerifyChain(ctx, chain, VerifyOptions{Roots: "bogus"}); err == nil {
+		t.Error("VerifyChain() with unknown root source should fail")
+	}
+}
+
+func TestVerifyFailureReason(t *testing.T) {
+	wrap := func(err error) error {
+		return &verifierrors.VerifiError{Op: "verify chain", Err: fmt.Errorf("%w: %w", verifierrors.ErrVerificationFailed, err)}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "unknown authority", err: wrap(x509.UnknownAuthorityError{}), want: FailureUnknownAuthority},
+		{name: "expired", err: wrap(x509.CertificateInvalidError{Reason: x509.Expired}), want: FailureExpired},
+		{name: "EKU", err: wrap(x509.CertificateInvalidError{Reason: x509.IncompatibleUsage}), want: FailureIncompatibleUsage},
+		{name: "name mismatch", err: wrap(x509.HostnameError{Host: "example.com"}), want: FailureNameMismatch},
+		{name: "other invalid", err: wrap(x509.CertificateInvalidError{Reason: x509.NotAuthorizedToSign}), want: FailureOther},
+		{name: "other", err: errors.New("boom"), want: FailureOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyFailureReason(tt.err); got != tt.want {
+				t.Errorf("VerifyFailureReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_MissingIssuers(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	intermediatePEM, rootPEM := generateTestIntermediate(t, "Corp Issuing CA")
+	intermediate, _, err := ValidateCertChain(intermediatePEM, false)
+	if err != nil {
+		t.Fatalf("ValidateCertChain() error = %v", err)
+	}
+	withRoot, _, err := ValidateCertChain(append(append([]byte{}, intermediatePEM...), rootPEM...), false)
+	if err != nil {
+		t.Fatalf("ValidateCertChain() error = %v", err)
+	}
+
+	missing, err := store.MissingIssuers(intermediate)
+	if err != nil {
+		t.Fatalf("MissingIssuers() error = %v", err)
+	}
+	if len(missing) != 1 {
+		t.Fatalf("MissingIssuers() = %d certs, want 1", len(missing))
+	}
+
+	// Root in the same input
+	if missing, _ := store.MissingIssuers(withRoot); len(missing) != 0 {
+		t.Errorf("MissingIssuers() with root in input = %d certs, want 0", len(missing))
+	}
+
+	// Root already in the user store
+	rootPath := filepath.Join(tmpDir, "root.pem")
+	if err := os.WriteFile(rootPath, rootPEM, 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, rootPath, "corp-root", false); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+	if missing, _ := store.MissingIssuers(intermediate); len(missing) != 0 {
+		t.Errorf("MissingIssuers() with root in store = %d certs, want 0", len(missing))
+	}
+}
+
+func TestStore_DownloadPool(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	store, err := NewStore(tmpDir)
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := store.Init(ctx, false); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	// Trust the server's certificate, as for an intercepting proxy's CA
+	certPath := filepath.Join(tmpDir, "proxy.pem")
+	if err := os.WriteFile(certPath, EncodeCertsPEM([]*x509.Certificate{server.Certificate()}), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := store.AddCert(ctx, certPath, "proxy", false); err != nil {
+		t.Fatalf("AddCert() error = %v", err)
+	}
+
+	// The embedded pool does not depend on the downloaded Mozilla bundle
+	if err := os.Remove(store.mozillaBundlePath()); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	for _, roots := range []string{DownloadRootsCombined, DownloadRootsEmbedded} {
+		pool, err := store.DownloadPool(ctx, roots)
+		if err != nil {
+			t.Fatalf("DownloadPool(%s) error = %v", roots, err)
+		}
+		if _, err := server.Certificate().Verify(x509.VerifyOptions{Roots: pool}); err != nil {
+			t.Errorf("DownloadPool(%s) should trust the user certificate: %v", roots, err)
+		}
+	}
+
+	if pool, err := store.DownloadPool(ctx, DownloadRootsSystem); err != nil || pool != nil {
+		t.Errorf("DownloadPool(system) = %v, %v, want nil pool for the system roots", pool, err)
+	}
+	if _, err := store.DownloadPool(ctx, "bogus"); err == nil {
+		t.Error("DownloadPool() with unknown root source should fail")
+	}
+}
@@ -1,11 +1,13 @@
 package certstore
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/pem"
 	"fmt"
+	"strings"
 	"time"
 
 	verifierrors "github.com/princespaghetti/verifi/internal/errors"
@@ -16,57 +18,190 @@ type CertMetadata struct {
 	Subject     string
 	Fingerprint string
 	Expires     time.Time
+	IsCA        bool
+	Role        CertRole
 }
 
+// CertRole classifies a certificate's place in a chain.
+type CertRole string
+
+// Certificate roles reported by ClassifyCert.
+const (
+	RoleRoot         CertRole = "root"
+	RoleIntermediate CertRole = "intermediate"
+	RoleLeaf         CertRole = "leaf"
+)
+
 // ValidateCert validates a PEM-encoded certificate and extracts metadata.
 // If force is false, expired certificates will return an error.
 // If force is true, expired certificates are allowed but still validated for format.
+//
+// Every PEM block in data is validated; the first certificate and its metadata
+// are returned. Use ValidateCertChain to get all certificates in the input.
 func ValidateCert(data []byte, force bool) (*x509.Certificate, *CertMetadata, error) {
-	// Decode PEM block
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return nil, nil, &verifierrors.VerifiError{
-			Op:  "validate certificate",
-			Err: verifierrors.ErrInvalidPEM,
-		}
+	certs, metas, err := ValidateCertChain(data, force)
+	if err != nil {
+		return nil, nil, err
 	}
+	return certs[0], metas[0], nil
+}
 
-	// Only accept CERTIFICATE blocks
-	if block.Type != "CERTIFICATE" {
-		return nil, nil, &verifierrors.VerifiError{
-			Op:  "validate certificate",
-			Err: fmt.Errorf("invalid PEM block type: %s (expected CERTIFICATE)", block.Type),
+// ValidateCertChain validates every PEM block in data and extracts metadata for
+// each certificate, in file order. This supports chain files that hold a root
+// plus intermediates. Any block that is not a valid CERTIFICATE fails the whole
+// input, so nothing after the first block can slip into the bundle unchecked.
+// Private key blocks are reported with ErrPrivateKey before anything else.
+func ValidateCertChain(data []byte, force bool) ([]*x509.Certificate, []*CertMetadata, error) {
+	// Refuse key material outright, whatever else the input contains
+	for _, blockType := range NonCertificateBlocks(data) {
+		if IsPrivateKeyBlock(blockType) {
+			return nil, nil, &verifierrors.VerifiError{
+				Op:  "validate certificate",
+				Err: fmt.Errorf("%w: refusing to store %s block", verifierrors.ErrPrivateKey, blockType),
+			}
 		}
 	}
 
-	// Parse x509 certificate
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return nil, nil, &verifierrors.VerifiError{
-			Op:  "parse certificate",
-			Err: fmt.Errorf("invalid x509 certificate: %w", err),
+	var certs []*x509.Certificate
+	var metas []*CertMetadata
+
+	remaining := data
+	for {
+		// Decode next PEM block
+		block, rest := pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+		remaining = rest
+		index := len(certs) + 1
+
+		// Only accept CERTIFICATE blocks
+		if block.Type != "CERTIFICATE" {
+			return nil, nil, &verifierrors.VerifiError{
+				Op:  "validate certificate",
+				Err: fmt.Errorf("%w: unexpected block type %s (expected CERTIFICATE)", verifierrors.ErrInvalidPEM, block.Type),
+			}
+		}
+
+		// Parse x509 certificate
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, &verifierrors.VerifiError{
+				Op:  "parse certificate",
+				Err: fmt.Errorf("invalid x509 certificate (block %d): %w", index, err),
+			}
+		}
+
+		// Check expiry unless force is enabled
+		if !force && time.Now().After(cert.NotAfter) {
+			return nil, nil, &verifierrors.VerifiError{
+				Op:  "validate certificate",
+				Err: fmt.Errorf("%w: %s", verifierrors.ErrCertExpired, cert.Subject.String()),
+			}
 		}
+
+		certs = append(certs, cert)
+		metas = append(metas, certMetadata(cert))
 	}
 
-	// Check expiry unless force is enabled
-	now := time.Now()
-	if !force && now.After(cert.NotAfter) {
+	if len(certs) == 0 {
 		return nil, nil, &verifierrors.VerifiError{
 			Op:  "validate certificate",
-			Err: verifierrors.ErrCertExpired,
+			Err: verifierrors.ErrInvalidPEM,
 		}
 	}
 
-	// Compute SHA256 fingerprint of the DER-encoded certificate
-	hash := sha256.Sum256(cert.Raw)
-	fingerprint := "sha256:" + hex.EncodeToString(hash[:])
+	return certs, metas, nil
+}
+
+// NonCertificateBlocks returns the types of all PEM blocks in data that are
+// not CERTIFICATE blocks, in file order.
+func NonCertificateBlocks(data []byte) []string {
+	var types []string
+	remaining := data
+	for {
+		block, rest := pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+		remaining = rest
+		if block.Type != "CERTIFICATE" {
+			types = append(types, block.Type)
+		}
+	}
+	return types
+}
 
-	// Extract metadata
-	metadata := &CertMetadata{
+// IsPrivateKeyBlock reports whether a PEM block type holds private key
+// material (e.g. "PRIVATE KEY", "RSA PRIVATE KEY", "ENCRYPTED PRIVATE KEY").
+func IsPrivateKeyBlock(blockType string) bool {
+	return strings.Contains(blockType, "PRIVATE KEY")
+}
+
+// certMetadata extracts metadata from a parsed certificate.
+func certMetadata(cert *x509.Certificate) *CertMetadata {
+	return &CertMetadata{
 		Subject:     cert.Subject.String(),
-		Fingerprint: fingerprint,
+		Fingerprint: Fingerprint(cert),
 		Expires:     cert.NotAfter,
+		IsCA:        cert.BasicConstraintsValid && cert.IsCA,
+		Role:        ClassifyCert(cert),
+	}
+}
+
+// ClassifyCert determines whether a certificate is a root, an intermediate or
+// a leaf from its BasicConstraints, KeyUsage and self-signature.
+func ClassifyCert(cert *x509.Certificate) CertRole {
+	selfSigned := IsSelfSigned(cert)
+
+	// Version 1 certificates carry no extensions; a self-signed one can only
+	// be used as a trust anchor
+	if cert.Version < 3 && !cert.BasicConstraintsValid {
+		if selfSigned {
+			return RoleRoot
+		}
+		return RoleLeaf
+	}
+
+	if !cert.BasicConstraintsValid || !cert.IsCA {
+		return RoleLeaf
 	}
 
-	return cert, metadata, nil
+	// A CA whose key usage excludes certificate signing cannot issue anything
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return RoleLeaf
+	}
+
+	if selfSigned {
+		return RoleRoot
+	}
+	return RoleIntermediate
+}
+
+// IsSelfSigned reports whether cert names itself as issuer and is signed by
+// its own key.
+func IsSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// Fingerprint returns the SHA256 fingerprint of the DER-encoded certificate
+// in the "sha256:<hex>" form used throughout the store.
+func Fingerprint(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// EncodeCertsPEM re-encodes certificates as canonical PEM blocks.
+func EncodeCertsPEM(certs []*x509.Certificate) []byte {
+	var out []byte
+	for _, cert := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: cert.Raw,
+		})...)
+	}
+	return out
 }
@@ -60,6 +60,57 @@ func generateTestCert(t *testing.T, subject string, notBefore, notAfter time.Tim
 	return certPEM
 }
 
+// generateTestLeafCert generates a non-CA certificate signed by a fresh CA.
+// It returns the leaf PEM followed by the issuing CA PEM.
+func generateTestLeafCert(t *testing.T, subject string) (leafPEM, caPEM []byte) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate CA key: %v", err)
+	}
+	caTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: subject + " Issuing CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, &caTemplate, &caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("Failed to parse CA certificate: %v", err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate leaf key: %v", err)
+	}
+	leafTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: subject},
+		DNSNames:              []string{subject},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, &leafTemplate, caCert, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("Failed to create leaf certificate: %v", err)
+	}
+
+	leafPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+	caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	return leafPEM, caPEM
+}
+
 // Test constants
 const (
 	invalidPEM = `This is not a valid PEM certificate`
@@ -210,3 +261,192 @@ func TestValidateCert_Fingerprint(t *testing.T) {
 		t.Errorf("ValidateCert() fingerprints should match, got %v and %v", meta1.Fingerprint, meta2.Fingerprint)
 	}
 }
+
+func TestValidateCertChain(t *testing.T) {
+	root := generateTestCert(t, "Root CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	other := generateTestCert(t, "Other CA", time.Now().Add(-24*time.Hour), time.Now().Add(30*24*time.Hour))
+	expired := generateTestCert(t, "Expired CA", time.Now().Add(-48*time.Hour), time.Now().Add(-24*time.Hour))
+
+	t.Run("all blocks are returned in order", func(t *testing.T) {
+		data := append(append([]byte{}, root...), other...)
+		certs, metas, err := ValidateCertChain(data, false)
+		if err != nil {
+			t.Fatalf("ValidateCertChain() error = %v", err)
+		}
+		if len(certs) != 2 || len(metas) != 2 {
+			t.Fatalf("ValidateCertChain() returned %d certs, want 2", len(certs))
+		}
+		if metas[0].Subject != "CN=Root CA" || metas[1].Subject != "CN=Other CA" {
+			t.Errorf("subjects = %q, %q", metas[0].Subject, metas[1].Subject)
+		}
+		if !metas[0].IsCA {
+			t.Error("root should be marked as CA")
+		}
+	})
+
+	t.Run("expired block after the first fails", func(t *testing.T) {
+		data := append(append([]byte{}, root...), expired...)
+		_, _, err := ValidateCertChain(data, false)
+		if !errors.Is(err, verifierrors.ErrCertExpired) {
+			t.Errorf("ValidateCertChain() error = %v, want ErrCertExpired", err)
+		}
+	})
+
+	t.Run("non-certificate block after the first fails", func(t *testing.T) {
+		data := append(append([]byte{}, root...), []byte(nonCertPEM)...)
+		if _, _, err := ValidateCertChain(data, false); err == nil {
+			t.Error("ValidateCertChain() should reject trailing non-certificate blocks")
+		}
+	})
+
+	t.Run("private key is refused with ErrPrivateKey", func(t *testing.T) {
+		// Key placed after an expired certificate still reports the key
+		data := append(append([]byte{}, expired...), []byte(nonCertPEM)...)
+		_, _, err := ValidateCertChain(data, false)
+		if !errors.Is(err, verifierrors.ErrPrivateKey) {
+			t.Errorf("ValidateCertChain() error = %v, want ErrPrivateKey", err)
+		}
+	})
+
+	t.Run("other block types are invalid PEM", func(t *testing.T) {
+		data := append(append([]byte{}, root...), []byte("-----BEGIN X509 CRL-----\nMAA=\n-----END X509 CRL-----\n")...)
+		_, _, err := ValidateCertChain(data, false)
+		if !errors.Is(err, verifierrors.ErrInvalidPEM) {
+			t.Errorf("ValidateCertChain() error = %v, want ErrInvalidPEM", err)
+		}
+	})
+
+	t.Run("ValidateCert rejects a bad trailing block", func(t *testing.T) {
+		data := append(append([]byte{}, root...), []byte(nonCertPEM)...)
+		if _, _, err := ValidateCert(data, false); err == nil {
+			t.Error("ValidateCert() should validate every block")
+		}
+	})
+}
+
+func TestNonCertificateBlocks(t *testing.T) {
+	root := generateTestCert(t, "Root CA", time.Now().Add(-24*time.Hour), time.Now().Add(365*24*time.Hour))
+	data := append(append([]byte{}, root...), []byte(nonCertPEM)...)
+
+	got := NonCertificateBlocks(data)
+	if len(got) != 1 || got[0] != "RSA PRIVATE KEY" {
+		t.Fatalf("NonCertificateBlocks() = %v, want [RSA PRIVATE KEY]", got)
+	}
+	if !IsPrivateKeyBlock(got[0]) {
+		t.Errorf("IsPrivateKeyBlock(%q) = false, want true", got[0])
+	}
+	if IsPrivateKeyBlock("CERTIFICATE REQUEST") {
+		t.Error("IsPrivateKeyBlock(CERTIFICATE REQUEST) = true, want false")
+	}
+	if blocks := NonCertificateBlocks(root); len(blocks) != 0 {
+		t.Errorf("NonCertificateBlocks() on a plain certificate = %v, want none", blocks)
+	}
+}
+
+// generateTestIntermediate creates a root CA and an intermediate CA signed by it.
+// It returns the intermediate PEM followed by the root PEM.
+func generateTestIntermediate(t *testing.T, subject string) (intermediatePEM, rootPEM []byte) {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate root key: %v", err)
+	}
+	rootTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: subject + " Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, &rootTemplate, &rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("Failed to create root certificate: %v", err)
+	}
+	rootCert, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatalf("Failed to parse root certificate: %v", err)
+	}
+
+	intKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate intermediate key: %v", err)
+	}
+	intTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: subject},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(180 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	intDER, err := x509.CreateCertificate(rand.Reader, &intTemplate, rootCert, &intKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("Failed to create intermediate certificate: %v", err)
+	}
+
+	intermediatePEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: intDER})
+	rootPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER})
+	return intermediatePEM, rootPEM
+}
+
+func TestClassifyCert(t *testing.T) {
+	parse := func(t *testing.T, data []byte) *x509.Certificate {
+		t.Helper()
+		block, _ := pem.Decode(data)
+		if block == nil {
+			t.Fatal("failed to decode PEM")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("ParseCertificate() error = %v", err)
+		}
+		return cert
+	}
+
+	root := generateTestCert(t, "Root CA", time.Now().Add(-time.Hour), time.Now().Add(24*time.Hour))
+	leaf, _ := generateTestLeafCert(t, "server.corp.example")
+	intermediate, _ := generateTestIntermediate(t, "Issuing CA")
+
+	// A CA flag without the certSign key usage cannot issue certificates
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	noSignTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(3),
+		Subject:               pkix.Name{CommonName: "No CertSign"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	noSignDER, err := x509.CreateCertificate(rand.Reader, &noSignTemplate, &noSignTemplate, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	noSign := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: noSignDER})
+
+	tests := []struct {
+		name string
+		data []byte
+		want CertRole
+	}{
+		{name: "self-signed CA", data: root, want: RoleRoot},
+		{name: "intermediate CA", data: intermediate, want: RoleIntermediate},
+		{name: "server certificate", data: leaf, want: RoleLeaf},
+		{name: "CA without certSign", data: noSign, want: RoleLeaf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyCert(parse(t, tt.data)); got != tt.want {
+				t.Errorf("ClassifyCert() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
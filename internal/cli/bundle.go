@@ -2,9 +2,13 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -15,8 +19,14 @@ import (
 )
 
 var (
-	bundleJSON bool
-	bundleURL  string
+	bundleJSON       bool
+	bundleURLs       []string
+	bundleSHA256     string
+	bundleForce      bool
+	bundleNoFallback bool
+	bundleClear      bool
+	bundleQuorum     int
+	bundleTrust      string
 )
 
 // bundleCmd represents the bundle command.
@@ -26,14 +36,19 @@ var bundleCmd = &cobra.Command{
 	Long: `Manage the Mozilla CA certificate bundle.
 
 Commands:
-  info   - Display information about the current Mozilla bundle
-  update - Download and update the Mozilla bundle from curl.se
+  info    - Display information about the current Mozilla bundle
+  update  - Download and update the Mozilla bundle from curl.se or mirrors
+  mirrors - Show or set the mirrors bundle update downloads from
+  verify  - Check the Mozilla bundle against its recorded checksum
+  reset   - Reset the Mozilla bundle to the embedded version
 
 Examples:
   verifi bundle info
   verifi bundle info --json
   verifi bundle update
-  verifi bundle update --url https://custom-mirror.example.com/cacert.pem`,
+  verifi bundle update --url https://custom-mirror.example.com/cacert.pem
+  verifi bundle mirrors https://internal-mirror.corp.com/cacert.pem
+  verifi bundle verify`,
 }
 
 // bundleInfoCmd represents the bundle info command.
@@ -64,18 +79,76 @@ var bundleUpdateCmd = &cobra.Command{
 
 By default, downloads from: https://curl.se/ca/cacert.pem
 
+Mirrors given with --url (repeatable), or else those saved with
+'verifi bundle mirrors', are tried in order, with curl.se as the final
+fallback unless --no-fallback is given. Each mirror is retried with
+exponential backoff on network errors and 5xx responses before moving on
+to the next; responses larger than 16MB are rejected. file:// URLs, such as
+a bundle vendored into a repository, are read from disk.
+
+With --quorum N, the bundle is downloaded from every mirror (curl.se
+included) and accepted only if at least N of them serve the same set of
+certificates; comments, order and formatting may differ. If too few agree,
+a per-source list of the roots each one adds (+) or lacks (-) is printed
+and nothing is changed (exit code 3).
+
 The bundle is:
   1. Downloaded to a temporary file
-  2. Verified (valid PEM format, minimum cert count)
-  3. Checked for degradation (warns if cert count drops >20%)
-  4. Atomically replaces the current Mozilla bundle
-  5. Triggers rebuild of the combined bundle
-  6. Updates metadata with new version information
+  2. Checked against the SHA256 checksum published next to it (<url>.sha256)
+     and against --sha256, if given
+  3. Verified (valid PEM format, minimum cert count)
+  4. Checked for degradation (warns if cert count drops >20%)
+  5. Atomically replaces the current Mozilla bundle
+  6. Triggers rebuild of the combined bundle
+  7. Updates metadata with new version information
+
+Downloads trust the combined bundle, so they work behind a TLS-intercepting
+proxy whose CA was added with 'verifi cert add' or 'verifi cert fetch'. Use
+--trust embedded to trust only the Mozilla bundle built into verifi plus
+your certificates (so a tampered bundle cannot vouch for its replacement),
+or --trust system for the operating system roots. HTTPS_PROXY, HTTP_PROXY
+and NO_PROXY are honored.
+
+A checksum mismatch aborts the update before anything is written, without
+trying further mirrors. Mirrors
+that do not publish a checksum are accepted with a warning; use --sha256 to
+pin the expected checksum for them.
+
+The download is conditional (ETag / Last-Modified): if the installed bundle
+came from the same mirror and the server reports it unchanged, the command
+exits with "already up to date" without touching the store. Use --force to
+download and install it regardless.
 
 Examples:
   verifi bundle update
-  verifi bundle update --url https://internal-mirror.corp.com/cacert.pem`,
-	RunE: runBundleUpdate,
+  verifi bundle update --url https://internal-mirror.corp.com/cacert.pem
+  verifi bundle update --url https://mirror-a.corp.com/cacert.pem --url https://mirror-b.corp.com/cacert.pem
+  verifi bundle update --url https://internal-mirror.corp.com/cacert.pem --no-fallback
+  verifi bundle update --quorum 2 --url https://internal-mirror.corp.com/cacert.pem --url file:///opt/vendor/cacert.pem
+  verifi bundle update --sha256 <sha256 of cacert.pem>
+  verifi bundle update --trust embedded
+  verifi bundle update --force`,
+	RunE:        runBundleUpdate,
+	Annotations: mutates,
+}
+
+// bundleMirrorsCmd represents the bundle mirrors command.
+var bundleMirrorsCmd = &cobra.Command{
+	Use:   "mirrors [url...]",
+	Short: "Show or set the mirrors bundle update downloads from",
+	Long: `Show or set the ordered list of mirrors that 'verifi bundle update' downloads
+the Mozilla CA bundle from when no --url is given. Mirrors are http(s) URLs
+or file:// URLs of a vendored copy.
+
+Mirrors are tried in order; curl.se is always tried last unless
+'bundle update --no-fallback' is used. With no arguments, the configured
+mirrors are shown. Giving URLs replaces the list; --clear removes it.
+
+Examples:
+  verifi bundle mirrors
+  verifi bundle mirrors https://mirror-a.corp.com/cacert.pem https://mirror-b.corp.com/cacert.pem
+  verifi bundle mirrors --clear`,
+	RunE: runBundleMirrors,
 }
 
 // bundleResetCmd represents the bundle reset command.
@@ -94,7 +167,27 @@ The combined bundle will be rebuilt after the reset.
 
 Examples:
   verifi bundle reset`,
-	RunE: runBundleReset,
+	RunE:        runBundleReset,
+	Annotations: mutates,
+}
+
+// bundleVerifyCmd represents the bundle verify command.
+var bundleVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the Mozilla CA bundle against its recorded checksum",
+	Long: `Re-check the Mozilla CA bundle on disk against the SHA256 checksum recorded
+when it was installed. For downloaded bundles, that checksum was itself
+verified against the published and/or pinned checksum at download time.
+
+Exit codes:
+  0 - The bundle matches its recorded checksum
+  2 - Store not initialized
+  3 - The bundle does not match (modified or corrupted)
+
+Examples:
+  verifi bundle verify`,
+	Args: cobra.NoArgs,
+	RunE: runBundleVerify,
 }
 
 func init() {
@@ -102,12 +195,22 @@ func init() {
 	bundleCmd.AddCommand(bundleInfoCmd)
 	bundleCmd.AddCommand(bundleUpdateCmd)
 	bundleCmd.AddCommand(bundleResetCmd)
+	bundleCmd.AddCommand(bundleVerifyCmd)
+	bundleCmd.AddCommand(bundleMirrorsCmd)
 
 	// Flags for info command
 	bundleInfoCmd.Flags().BoolVar(&bundleJSON, "json", false, "Output in JSON format")
 
 	// Flags for update command
-	bundleUpdateCmd.Flags().StringVar(&bundleURL, "url", fetcher.DefaultMozillaBundleURL, "URL to download bundle from")
+	bundleUpdateCmd.Flags().StringArrayVar(&bundleURLs, "url", nil, "URL to download bundle from; repeat to try several mirrors in order")
+	bundleUpdateCmd.Flags().IntVar(&bundleQuorum, "quorum", 0, "Fetch from every mirror and require this many to serve the same certificates")
+	bundleUpdateCmd.Flags().StringVar(&bundleTrust, "trust", certstore.DownloadRootsCombined, "Roots the download trusts: combined, embedded or system")
+	bundleUpdateCmd.Flags().BoolVar(&bundleNoFallback, "no-fallback", false, "Do not fall back to "+fetcher.DefaultMozillaBundleURL+" when all mirrors fail")
+	bundleUpdateCmd.Flags().StringVar(&bundleSHA256, "sha256", "", "Expected SHA256 checksum of the downloaded bundle")
+	bundleUpdateCmd.Flags().BoolVar(&bundleForce, "force", false, "Download and install the bundle even if it has not changed")
+
+	// Flags for mirrors command
+	bundleMirrorsCmd.Flags().BoolVar(&bundleClear, "clear", false, "Remove the configured mirrors")
 }
 
 // BundleInfoOutput represents the output of the bundle info command.
@@ -119,6 +222,9 @@ type BundleInfoOutput struct {
 	Generated time.Time `json:"generated"`
 	SizeBytes int64     `json:"size_bytes,omitempty"`
 	FilePath  string    `json:"file_path"`
+
+	ChecksumSources []string `json:"checksum_sources,omitempty"`
+	AgreedSources   []string `json:"agreed_sources,omitempty"`
 }
 
 func runBundleInfo(cmd *cobra.Command, args []string) error {
@@ -159,6 +265,9 @@ func runBundleInfo(cmd *cobra.Command, args []string) error {
 		Generated: metadata.MozillaBundle.Generated,
 		SizeBytes: sizeBytes,
 		FilePath:  mozillaBundlePath,
+
+		ChecksumSources: metadata.MozillaBundle.ChecksumSources,
+		AgreedSources:   metadata.MozillaBundle.AgreedSources,
 	}
 
 	// Output
@@ -188,10 +297,36 @@ func printBundleInfoHuman(info BundleInfoOutput) {
 	Field("File Path", info.FilePath)
 	EmptyLine()
 	Field("SHA256", info.SHA256)
+	if len(info.ChecksumSources) > 0 {
+		Field("Verified against", strings.Join(info.ChecksumSources, ", "))
+	}
+	if len(info.AgreedSources) > 0 {
+		Field("Agreed by", strings.Join(info.AgreedSources, ", "))
+	}
 	EmptyLine()
 }
 
 func runBundleUpdate(cmd *cobra.Command, args []string) error {
+	switch bundleTrust {
+	case certstore.DownloadRootsCombined, certstore.DownloadRootsEmbedded, certstore.DownloadRootsSystem:
+	default:
+		Error("Invalid --trust %q: expected %s, %s or %s", bundleTrust,
+			certstore.DownloadRootsCombined, certstore.DownloadRootsEmbedded, certstore.DownloadRootsSystem)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	if bundleSHA256 != "" {
+		if _, err := fetcher.NormalizeChecksum(bundleSHA256); err != nil {
+			Error("Invalid --sha256: %v", err)
+			os.Exit(verifierrors.ExitConfigError)
+		}
+	}
+	for _, u := range bundleURLs {
+		if !isMirrorURL(u) {
+			Error("Invalid --url %q: expected an http(s) or file:// URL", u)
+			os.Exit(verifierrors.ExitConfigError)
+		}
+	}
+
 	// Create store
 	store, err := certstore.NewStore("")
 	if err != nil {
@@ -213,19 +348,84 @@ func runBundleUpdate(cmd *cobra.Command, args []string) error {
 		os.Exit(verifierrors.ExitGeneralError)
 	}
 
+	if err := store.CheckWritable(); err != nil {
+		Error("%v", err)
+		fmt.Fprintf(os.Stderr, "Update verifi to the latest version to modify this store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
 	currentCertCount := metadata.MozillaBundle.CertCount
 
-	Info("Downloading Mozilla CA bundle from %s...", bundleURL)
+	urls := bundleMirrors(bundleURLs, metadata.BundleMirrors, !bundleNoFallback)
+	if len(urls) == 0 {
+		Error("No mirrors configured and --no-fallback given; nothing to download from")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	if bundleQuorum < 0 {
+		Error("--quorum must not be negative")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	if bundleQuorum > len(urls) {
+		Error("--quorum %d is more than the %d source(s) configured", bundleQuorum, len(urls))
+		fmt.Fprintf(os.Stderr, "Add sources with --url or 'verifi bundle mirrors'\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	switch {
+	case bundleQuorum > 0:
+		Info("Downloading Mozilla CA bundle from %d sources (quorum %d): %s...", len(urls), bundleQuorum, strings.Join(urls, ", "))
+	case len(urls) == 1:
+		Info("Downloading Mozilla CA bundle from %s...", urls[0])
+	default:
+		Info("Downloading Mozilla CA bundle (mirrors: %s)...", strings.Join(urls, ", "))
+	}
 
-	// Download bundle with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Download bundle with timeout; each mirror gets at most
+	// fetcher.DefaultMirrorTimeout of it
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
 
-	f := fetcher.NewFetcher(nil)
-	bundleData, err := f.FetchMozillaBundle(ctx, bundleURL)
+	// Only a bundle installed from the same mirror can be revalidated
+	opts := fetcher.FetchOptions{SHA256: bundleSHA256}
+	installed := metadata.MozillaBundle
+
+	// A mirror that published a checksum before must still publish one
+	for _, source := range installed.ChecksumSources {
+		if url, ok := strings.CutSuffix(source, fetcher.ChecksumSuffix); ok {
+			opts.RequireChecksum = append(opts.RequireChecksum, url)
+		}
+	}
+	if !bundleForce {
+		opts.Cached = fetcher.Validators{ETag: installed.ETag, LastModified: installed.LastModified}
+		opts.CachedURL = installed.Source
+	}
+
+	roots, err := store.DownloadPool(ctx, bundleTrust)
 	if err != nil {
-		Error("Failed to download bundle: %v", err)
-		os.Exit(verifierrors.ExitNetworkError)
+		Error("Failed to load the roots to trust for the download: %v", err)
+		fmt.Fprintf(os.Stderr, "Use --trust system to trust the operating system roots instead\n")
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	f := fetcher.NewFetcher(fetcher.NewHTTPClient(roots))
+	var download *fetcher.VerifiedBundle
+	var agreed []string
+	if bundleQuorum > 0 {
+		download, agreed = fetchBundleQuorum(ctx, f, urls, opts)
+	} else {
+		download = fetchBundleFromMirrors(ctx, f, urls, opts, installed)
+	}
+	if download.NotModified || (!bundleForce && isInstalledBundle(installed, download.URL, download)) {
+		Success("Mozilla CA bundle is already up to date")
+		if len(urls) > 1 {
+			FieldIndented("Checked with", download.URL, 2)
+		}
+		return nil
+	}
+
+	bundleData := download.Data
+	if len(download.ChecksumSources) == 0 {
+		Warning("No checksum is published at %s%s; the bundle could not be checked", download.URL, fetcher.ChecksumSuffix)
+		fmt.Fprintf(os.Stderr, "Use --sha256 to pin the expected checksum for this mirror.\n")
 	}
 
 	// Verify bundle
@@ -243,53 +443,36 @@ func runBundleUpdate(cmd *cobra.Command, args []string) error {
 		_, _ = fmt.Scanln() // Wait for user confirmation (ignore error - continue anyway)
 	}
 
-	// Write new bundle atomically
-	mozillaBundlePath := filepath.Join(store.BasePath(), "certs", "bundles", "mozilla-ca-bundle.pem")
-	tempPath := mozillaBundlePath + ".tmp"
-
-	if err := os.WriteFile(tempPath, bundleData, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to write temp bundle: %v\n", err)
-		os.Exit(verifierrors.ExitGeneralError)
-	}
-
-	if err := os.Rename(tempPath, mozillaBundlePath); err != nil {
-		_ = os.Remove(tempPath)
-		fmt.Fprintf(os.Stderr, "Error: Failed to replace bundle: %v\n", err)
-		os.Exit(verifierrors.ExitGeneralError)
-	}
-
-	// Update metadata
+	// Replace the bundle, update metadata and rebuild in one transaction
 	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel2()
 
-	updateErr := store.UpdateMetadata(ctx2, func(md *certstore.Metadata) error {
-		// Parse Mozilla date from bundle header
-		mozillaDateStr := fetcher.ExtractMozillaDateString(bundleData)
-
-		// Update Mozilla bundle info
-		md.MozillaBundle = certstore.BundleInfo{
-			Generated: time.Now(),
-			SHA256:    fetcher.ComputeSHA256(bundleData),
-			CertCount: verifyResult.CertCount,
-			Source:    bundleURL,
-			Version:   mozillaDateStr,
-		}
-
-		// Rebuild combined bundle
-		return store.RebuildBundle(ctx2, md)
+	updateErr := store.UpdateMozillaBundle(ctx2, bundleData, certstore.BundleInfo{
+		Generated: time.Now(),
+		SHA256:    download.SHA256,
+		CertCount: verifyResult.CertCount,
+		Source:    download.URL,
+		Version:   fetcher.ExtractMozillaDateString(bundleData),
+
+		ChecksumSources: download.ChecksumSources,
+		ETag:            download.Validators.ETag,
+		LastModified:    download.Validators.LastModified,
+		AgreedSources:   agreed,
 	})
 
 	if updateErr != nil {
-		Error("Failed to update metadata and rebuild bundle: %v", updateErr)
-		fmt.Fprintf(os.Stderr, "The Mozilla bundle was updated but the combined bundle may be out of sync.\n")
-		fmt.Fprintf(os.Stderr, "Run 'verifi doctor' to repair the store.\n")
+		Error("Failed to update bundle: %v", updateErr)
+		fmt.Fprintf(os.Stderr, "The store was left unchanged.\n")
 		os.Exit(verifierrors.ExitGeneralError)
 	}
 
 	// Show success message
 	EmptyLine()
 	Success("Bundle updated successfully")
-	FieldIndented("Downloaded from", bundleURL, 2)
+	FieldIndented("Downloaded from", download.URL, 2)
+	if len(agreed) > 0 {
+		FieldIndented("Agreed by", fmt.Sprintf("%d of %d sources (quorum %d)", len(agreed), len(urls), bundleQuorum), 2)
+	}
 	if verifyResult.HasDateInHeader {
 		FieldIndented("Mozilla date", verifyResult.MozillaDate.Format("January 2, 2006"), 2)
 	}
@@ -356,3 +539,277 @@ func runBundleReset(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// fetchBundleFromMirrors downloads the bundle from the first mirror that
+// serves it, exiting on failure.
+func fetchBundleFromMirrors(ctx context.Context, f *fetcher.Fetcher, urls []string, opts fetcher.FetchOptions, installed certstore.BundleInfo) *fetcher.VerifiedBundle {
+	download, err := f.FetchFromMirrors(ctx, urls, opts)
+	if err == nil && download.NotModified && bundleSHA256 != "" {
+		// The pin must match what is installed, since that is what stays
+		pin, _ := fetcher.NormalizeChecksum(bundleSHA256)
+		if pin != installed.SHA256 {
+			err = fmt.Errorf("pinned checksum: %w: expected %s, installed bundle is %s", verifierrors.ErrChecksumMismatch, pin, installed.SHA256)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, verifierrors.ErrChecksumMismatch) || errors.Is(err, verifierrors.ErrChecksumMissing) {
+			Error("Bundle checksum verification failed: %v", err)
+			fmt.Fprintf(os.Stderr, "The downloaded bundle was discarded; nothing was changed.\n")
+			os.Exit(verifierrors.ExitCertError)
+		}
+		var mirrorErr *fetcher.MirrorError
+		if errors.As(err, &mirrorErr) && len(mirrorErr.Attempts) > 1 {
+			Error("Failed to download bundle from any mirror")
+			for _, attempt := range mirrorErr.Attempts {
+				fmt.Fprintf(os.Stderr, "  %s: %s\n", attempt.URL, attempt.Error)
+			}
+		} else {
+			Error("Failed to download bundle: %v", err)
+		}
+		printInterceptionHint(err)
+		os.Exit(verifierrors.ExitNetworkError)
+	}
+
+	for _, attempt := range download.Failed() {
+		Warning("Mirror %s failed: %s", attempt.URL, attempt.Error)
+	}
+	return download
+}
+
+// fetchBundleQuorum downloads the bundle from every source and returns it
+// with the sources that agree on it, exiting if fewer than --quorum do.
+func fetchBundleQuorum(ctx context.Context, f *fetcher.Fetcher, urls []string, opts fetcher.FetchOptions) (*fetcher.VerifiedBundle, []string) {
+	result, err := f.FetchQuorum(ctx, urls, bundleQuorum, opts)
+	if err != nil {
+		if result == nil {
+			Error("Failed to download bundle: %v", err)
+			os.Exit(verifierrors.ExitNetworkError)
+		}
+		Error("Bundle rejected: %v", err)
+		printQuorumReport(os.Stderr, result)
+		fmt.Fprintf(os.Stderr, "Nothing was changed.\n")
+		sourceErrs := make([]error, 0, len(result.Sources))
+		for _, source := range result.Sources {
+			sourceErrs = append(sourceErrs, source.Err)
+		}
+		printInterceptionHint(errors.Join(sourceErrs...))
+		if errors.Is(err, verifierrors.ErrSourcesDisagree) {
+			os.Exit(verifierrors.ExitCertError)
+		}
+		os.Exit(verifierrors.ExitNetworkError)
+	}
+
+	// Accepted, but say so if any source failed or served something else
+	if len(result.Agreeing) < len(urls) {
+		Warning("%d of %d sources agree; the others failed or differ", len(result.Agreeing), len(urls))
+		printQuorumReport(os.Stdout, result)
+	}
+	return result.Accepted(), result.Agreeing
+}
+
+// printInterceptionHint explains how to trust a TLS-intercepting proxy if err
+// includes a server certificate that the download did not trust.
+func printInterceptionHint(err error) {
+	var untrusted *fetcher.UntrustedCertError
+	if !errors.As(err, &untrusted) {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nThe certificate of %s was issued by %s, which the download does not trust (--trust %s).\n", untrusted.Host, untrusted.Issuer, bundleTrust)
+	fmt.Fprintf(os.Stderr, "If a proxy intercepts TLS on this network, add its CA and retry:\n")
+	fmt.Fprintf(os.Stderr, "  verifi cert fetch %s\n", untrusted.Host)
+}
+
+// printQuorumReport lists every source of a quorum fetch and, for those that
+// served a different bundle, which roots it adds (+) or lacks (-) compared
+// with the reference bundle.
+func printQuorumReport(w io.Writer, result *fetcher.QuorumResult) {
+	reference := result.ReferenceCerts()
+	for i, source := range result.Sources {
+		switch {
+		case source.Err != nil:
+			_, _ = fmt.Fprintf(w, "  %s: failed: %v\n", source.URL, source.Err)
+		case i == result.Reference:
+			_, _ = fmt.Fprintf(w, "  %s: %d certificates (reference)\n", source.URL, len(source.Certs))
+		default:
+			added, removed := reference.Diff(source.Certs)
+			if len(added) == 0 && len(removed) == 0 {
+				_, _ = fmt.Fprintf(w, "  %s: %d certificates, same as reference\n", source.URL, len(source.Certs))
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "  %s: %d certificates, differs from reference:\n", source.URL, len(source.Certs))
+			for _, entry := range added {
+				_, _ = fmt.Fprintf(w, "    + %s (%s)\n", entry.Subject, entry.Fingerprint)
+			}
+			for _, entry := range removed {
+				_, _ = fmt.Fprintf(w, "    - %s (%s)\n", entry.Subject, entry.Fingerprint)
+			}
+		}
+	}
+}
+
+// bundleMirrors returns the URLs bundle update tries, in order: the --url
+// flags if any were given, else the saved mirrors, followed by the curl.se
+// bundle as a fallback unless it is already listed or fallback is off.
+func bundleMirrors(flagURLs, saved []string, fallback bool) []string {
+	urls := flagURLs
+	if len(urls) == 0 {
+		urls = saved
+	}
+
+	var result []string
+	seen := make(map[string]bool)
+	for _, mirror := range urls {
+		if mirror = strings.TrimSpace(mirror); mirror != "" && !seen[mirror] {
+			seen[mirror] = true
+			result = append(result, mirror)
+		}
+	}
+	if fallback && !seen[fetcher.DefaultMozillaBundleURL] {
+		result = append(result, fetcher.DefaultMozillaBundleURL)
+	}
+	return result
+}
+
+// isInstalledBundle reports whether download is the bundle already installed
+// from url, with the same cache validators, so installing it would change
+// nothing. Servers that ignore conditional requests end up here.
+func isInstalledBundle(installed certstore.BundleInfo, url string, download *fetcher.VerifiedBundle) bool {
+	return installed.Source == url &&
+		installed.SHA256 == download.SHA256 &&
+		installed.ETag == download.Validators.ETag &&
+		installed.LastModified == download.Validators.LastModified
+}
+
+func runBundleVerify(cmd *cobra.Command, args []string) error {
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	snap, err := store.Snapshot(ctx)
+	if err == nil {
+		err = snap.MetadataErr
+	}
+	if err != nil {
+		Error("Failed to read metadata: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+	if snap.MozillaBundleErr != nil {
+		Error("Failed to read Mozilla bundle: %v", snap.MozillaBundleErr)
+		fmt.Fprintf(os.Stderr, "Run 'verifi bundle reset' to restore the embedded bundle\n")
+		os.Exit(verifierrors.ExitCertError)
+	}
+
+	info := snap.Metadata.MozillaBundle
+	if err := fetcher.VerifyChecksum(snap.MozillaBundle, info.SHA256); err != nil {
+		Error("Mozilla bundle does not match its recorded checksum: %v", err)
+		fmt.Fprintf(os.Stderr, "The bundle was modified or corrupted after it was installed.\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi bundle update' or 'verifi bundle reset' to replace it\n")
+		os.Exit(verifierrors.ExitCertError)
+	}
+
+	Success("Mozilla bundle matches its recorded checksum")
+	FieldIndented("SHA256", info.SHA256, 2)
+	FieldIndented("Source", info.Source, 2)
+	if len(info.ChecksumSources) > 0 {
+		FieldIndented("Verified against", strings.Join(info.ChecksumSources, ", "), 2)
+	} else if info.Source != "embedded" {
+		FieldIndented("Verified against", "nothing (no checksum was published or pinned)", 2)
+	}
+
+	return nil
+}
+
+func runBundleMirrors(cmd *cobra.Command, args []string) error {
+	if bundleClear && len(args) > 0 {
+		Error("--clear cannot be combined with mirror URLs")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	for _, arg := range args {
+		if !isMirrorURL(arg) {
+			Error("Invalid mirror URL %q: expected an http(s) or file:// URL", arg)
+			os.Exit(verifierrors.ExitConfigError)
+		}
+	}
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	if bundleClear || len(args) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := store.SetBundleMirrors(ctx, bundleMirrors(args, nil, false)); err != nil {
+			Error("Failed to save mirrors: %v", err)
+			if errors.Is(err, verifierrors.ErrMetadataTooNew) {
+				os.Exit(verifierrors.ExitConfigError)
+			}
+			os.Exit(verifierrors.ExitGeneralError)
+		}
+		if bundleClear {
+			Success("Bundle mirrors removed; bundle update downloads from %s", fetcher.DefaultMozillaBundleURL)
+			return nil
+		}
+		Success("Bundle mirrors saved")
+	}
+
+	metadata, err := store.GetMetadata()
+	if err != nil {
+		Error("Failed to read metadata: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	if len(metadata.BundleMirrors) == 0 {
+		Info("No mirrors configured; bundle update downloads from %s", fetcher.DefaultMozillaBundleURL)
+		return nil
+	}
+	Header("Bundle Mirrors")
+	for i, mirror := range bundleMirrors(nil, metadata.BundleMirrors, true) {
+		label := fmt.Sprintf("%d", i+1)
+		if i == len(metadata.BundleMirrors) {
+			label += " (fallback)"
+		}
+		Field(label, mirror)
+	}
+	EmptyLine()
+	return nil
+}
+
+// isMirrorURL reports whether s is a URL the fetcher can download a bundle
+// from: http(s) with a host, or a file:// URL for a vendored copy.
+func isMirrorURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return u.Host != ""
+	case "file":
+		return u.Path != ""
+	}
+	return false
+}
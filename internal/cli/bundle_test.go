@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -424,3 +425,78 @@ func TestBundleUpdate_HTTPDownload(t *testing.T) {
 	assert.True(t, verifyResult.IsValid)
 	assert.Equal(t, 150, verifyResult.CertCount)
 }
+
+func TestIsInstalledBundle(t *testing.T) {
+	const url = "https://curl.se/ca/cacert.pem"
+	installed := certstore.BundleInfo{Source: url, SHA256: "abc", ETag: `"1"`}
+	download := &fetcher.VerifiedBundle{SHA256: "abc", Validators: fetcher.Validators{ETag: `"1"`}}
+
+	assert.True(t, isInstalledBundle(installed, url, download))
+	assert.False(t, isInstalledBundle(installed, "https://mirror.example.com/cacert.pem", download))
+
+	changed := *download
+	changed.SHA256 = "def"
+	assert.False(t, isInstalledBundle(installed, url, &changed))
+
+	// New validators are worth recording
+	revalidated := *download
+	revalidated.Validators.ETag = `"2"`
+	assert.False(t, isInstalledBundle(installed, url, &revalidated))
+}
+
+func TestBundleMirrors(t *testing.T) {
+	const mirrorA = "https://a.example.com/cacert.pem"
+	const mirrorB = "https://b.example.com/cacert.pem"
+	curl := fetcher.DefaultMozillaBundleURL
+
+	// curl.se is the fallback after the configured mirrors
+	assert.Equal(t, []string{curl}, bundleMirrors(nil, nil, true))
+	assert.Equal(t, []string{mirrorA, mirrorB, curl}, bundleMirrors(nil, []string{mirrorA, mirrorB}, true))
+
+	// --url replaces the saved mirrors
+	assert.Equal(t, []string{mirrorB, curl}, bundleMirrors([]string{mirrorB}, []string{mirrorA}, true))
+
+	// Listing curl.se keeps its position; duplicates are dropped
+	assert.Equal(t, []string{curl, mirrorA}, bundleMirrors([]string{curl, mirrorA, curl}, nil, true))
+
+	assert.Equal(t, []string{mirrorA}, bundleMirrors(nil, []string{mirrorA}, false))
+	assert.Empty(t, bundleMirrors(nil, nil, false))
+}
+
+func TestIsMirrorURL(t *testing.T) {
+	assert.True(t, isMirrorURL("https://mirror.example.com/cacert.pem"))
+	assert.True(t, isMirrorURL("http://mirror.example.com/cacert.pem"))
+	assert.True(t, isMirrorURL("file:///opt/vendor/cacert.pem"))
+	assert.False(t, isMirrorURL("ftp://mirror.example.com/cacert.pem"))
+	assert.False(t, isMirrorURL("https:///cacert.pem"))
+	assert.False(t, isMirrorURL("/opt/vendor/cacert.pem"))
+}
+
+func TestPrintQuorumReport(t *testing.T) {
+	bundle := fetcher.GetEmbeddedBundle()
+	// Drop the first certificate from the second source
+	end := bytes.Index(bundle, []byte("-----END CERTIFICATE-----")) + len("-----END CERTIFICATE-----")
+	tampered := bundle[end:]
+
+	reference := fetcher.ParseCertSet(bundle)
+	result := &fetcher.QuorumResult{
+		Quorum:    2,
+		Reference: 0,
+		Sources: []fetcher.SourceResult{
+			{URL: "https://curl.se/ca/cacert.pem", Certs: reference},
+			{URL: "https://mirror.example.com/cacert.pem", Certs: fetcher.ParseCertSet(tampered)},
+			{URL: "https://down.example.com/cacert.pem", Err: assert.AnError},
+		},
+	}
+
+	var buf bytes.Buffer
+	printQuorumReport(&buf, result)
+	out := buf.String()
+
+	_, removed := reference.Diff(result.Sources[1].Certs)
+	require.Len(t, removed, 1)
+	assert.Contains(t, out, "https://curl.se/ca/cacert.pem: "+fmt.Sprint(len(reference))+" certificates (reference)")
+	assert.Contains(t, out, "differs from reference")
+	assert.Contains(t, out, "    - "+removed[0].Subject)
+	assert.Contains(t, out, "https://down.example.com/cacert.pem: failed")
+}
@@ -2,10 +2,12 @@ package cli
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -20,6 +22,18 @@ var (
 	certStdin   bool
 	certJSON    bool
 	certExpired bool
+	certCAOnly  bool
+	certPass    string
+	certLeaf    bool
+	certTags    []string
+	certNote    string
+	certOwner   string
+	certHasTags []string
+	certTTL     string
+	certUntil   string
+	certDebug   string
+	certDir     string
+	certNameSrc string
 )
 
 // certCmd represents the cert command group.
@@ -31,21 +45,68 @@ var certCmd = &cobra.Command{
 
 // certAddCmd represents the cert add command.
 var certAddCmd = &cobra.Command{
-	Use:   "add [path]",
+	Use:   "add [path...]",
 	Short: "Add a certificate to the store",
 	Long: `Add a certificate to the user certificate store.
 
 The certificate will be validated before being added. By default, expired
 certificates are rejected. Use --force to add expired certificates.
 
+Files containing a full chain (e.g. a root plus intermediates) are supported.
+Every certificate in the file is validated and stored under the given name.
+Use --ca-only to import only the CA certificates from a chain.
+
+Each certificate is classified as a root, intermediate or leaf. Leaf
+(server) certificates are refused because trusting one rarely does what you
+want: add the CA that issued it instead, or use --allow-leaf to override.
+A warning is shown when an intermediate's issuer is in neither the Mozilla
+bundle nor the user store.
+
+The input format is detected automatically and converted to PEM. Supported
+formats are PEM, DER (.cer/.crt), base64 DER without headers, PKCS#7
+certificate bags (.p7b/.p7c) and PKCS#12 truststores (.p12/.pfx). Use
+--password for password-protected PKCS#12 files. Private keys in PKCS#12
+files are never stored.
+
 Use --stdin to read the certificate from standard input instead of a file.
 
+Several files (or quoted glob patterns) and --dir <directory> add many
+certificates at once. Each is named from its file name, or with
+--name-from cn from its common name; a single file without --name is named
+the same way. Every file is validated first and files that fail, or whose
+name or certificate is already in the store, are skipped and listed. The
+rest are added together with a single bundle rebuild: all or nothing.
+
+Use --tag, --note and --owner to label the certificate, e.g. with the team
+responsible for it. Labels can be changed later with 'verifi cert edit'.
+Re-adding an existing name keeps its labels unless new ones are given.
+
+Use --ttl or --until to trust the certificate only temporarily. Once the
+time has passed, the next verifi command drops it from the combined bundle;
+the file and metadata are kept until you remove it. Re-adding a name
+replaces its trust expiry.
+
+--import-debug-proxy mitmproxy|charles|burp finds the CA of a debugging
+proxy in its usual location under your home directory and adds it with the
+tool name and a "debug-proxy" tag. Unless --ttl or --until is given, it is
+trusted for 8 hours.
+
 Examples:
   verifi cert add /path/to/cert.pem --name corporate
   verifi cert add proxy-cert.pem --name proxy --force
+  verifi cert add corp-chain.pem --name corp --ca-only
+  verifi cert add corp-roots.p7b --name corp
+  verifi cert add truststore.p12 --name proxy --password changeit
+  verifi cert add corp-root.pem --name corp --tag corp --tag vpn --owner secops@corp.com
+  verifi cert add staging-ca.pem --name staging --ttl 2d
+  verifi cert add --dir ./corp-certs/ --tag corp
+  verifi cert add root.pem intermediates/*.crt --name-from cn
+  verifi cert add --import-debug-proxy mitmproxy
+  verifi cert add --import-debug-proxy charles --until "2026-10-17 18:00"
   curl https://internal.corp.com/ca.crt | verifi cert add --stdin --name internal`,
-	Args: cobra.MaximumNArgs(1),
-	RunE: runCertAdd,
+	Args:        cobra.ArbitraryArgs,
+	RunE:        runCertAdd,
+	Annotations: mutates,
 }
 
 // certListCmd represents the cert list command.
@@ -56,11 +117,14 @@ var certListCmd = &cobra.Command{
 
 By default, certificates are displayed in a table format. Use --json for
 machine-readable output. Use --expired to show only expired certificates.
+Use --tag to show only certificates carrying a tag; when given more than
+once, certificates must carry every tag.
 
 Examples:
   verifi cert list
   verifi cert list --json
-  verifi cert list --expired`,
+  verifi cert list --expired
+  verifi cert list --tag corp`,
 	RunE: runCertList,
 }
 
@@ -75,8 +139,9 @@ The certificate file will be deleted and the combined bundle will be rebuilt.
 Examples:
   verifi cert remove corporate
   verifi cert remove proxy`,
-	Args: cobra.ExactArgs(1),
-	RunE: runCertRemove,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runCertRemove,
+	Annotations: mutates,
 }
 
 // certInspectCmd represents the cert inspect command.
@@ -85,7 +150,7 @@ var certInspectCmd = &cobra.Command{
 	Short: "Show detailed information about a certificate",
 	Long: `Display detailed information about a specific user certificate.
 
-Shows the subject, issuer, expiration date, fingerprint, and other details.
+Shows the subject, issuer, expiration date, fingerprint, labels, and other details.
 
 Examples:
   verifi cert inspect corporate
@@ -105,14 +170,26 @@ func init() {
 	certCmd.AddCommand(certInspectCmd)
 
 	// cert add flags
-	certAddCmd.Flags().StringVar(&certName, "name", "", "Certificate name (required)")
+	certAddCmd.Flags().StringVar(&certName, "name", "", "Certificate name (derived from the file when omitted)")
 	certAddCmd.Flags().BoolVar(&certForce, "force", false, "Force add even if expired")
 	certAddCmd.Flags().BoolVar(&certStdin, "stdin", false, "Read certificate from stdin")
-	_ = certAddCmd.MarkFlagRequired("name") // Ignore error - setup failure would be caught at runtime
+	certAddCmd.Flags().BoolVar(&certCAOnly, "ca-only", false, "Import only CA certificates from a chain")
+	certAddCmd.Flags().StringVar(&certPass, "password", "", "Password for PKCS#12 files")
+	certAddCmd.Flags().BoolVar(&certLeaf, "allow-leaf", false, "Allow adding leaf (server) certificates")
+	certAddCmd.Flags().StringSliceVar(&certTags, "tag", nil, "Tag to label the certificate with (repeatable)")
+	certAddCmd.Flags().StringVar(&certNote, "note", "", "Free-form note about the certificate")
+	certAddCmd.Flags().StringVar(&certOwner, "owner", "", "Owner of the certificate (e.g. a team or email)")
+	certAddCmd.Flags().StringVar(&certTTL, "ttl", "", "Trust the certificate only for this long (e.g. 8h, 2d)")
+	certAddCmd.Flags().StringVar(&certUntil, "until", "", "Trust the certificate only until this time (e.g. \"2026-10-17 18:00\")")
+	certAddCmd.Flags().StringVar(&certDebug, "import-debug-proxy", "", "Import the CA of a debugging proxy: "+strings.Join(debugProxyTools(), ", "))
+	certAddCmd.Flags().StringVar(&certDir, "dir", "", "Add every certificate file in a directory")
+	certAddCmd.Flags().StringVar(&certNameSrc, "name-from", string(certstore.NameFromFile), "Derive names from the file name (file) or common name (cn) when --name is omitted")
+	certAddCmd.MarkFlagsMutuallyExclusive("ttl", "until")
 
 	// cert list flags
 	certListCmd.Flags().BoolVar(&certJSON, "json", false, "Output in JSON format")
 	certListCmd.Flags().BoolVar(&certExpired, "expired", false, "Show only expired certificates")
+	certListCmd.Flags().StringSliceVar(&certHasTags, "tag", nil, "Show only certificates with this tag (repeatable)")
 
 	// cert inspect flags
 	certInspectCmd.Flags().BoolVar(&certJSON, "json", false, "Output in JSON format")
@@ -123,8 +200,56 @@ func runCertAdd(cmd *cobra.Command, args []string) error {
 	var tempFile *os.File
 	var cleanupTemp bool
 
-	// Handle stdin vs file path
-	if certStdin {
+	trustUntil, err := parseTrustUntil(certTTL, certUntil, time.Now())
+	if err != nil {
+		Error("%v", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	tags := certTags
+
+	// A directory or several files are added in one transaction, with names
+	// derived from each file
+	if certDir != "" || len(args) > 1 {
+		if certStdin || certDebug != "" {
+			Error("Cannot combine --stdin or --import-debug-proxy with --dir or several files")
+			os.Exit(verifierrors.ExitConfigError)
+		}
+		if certName != "" {
+			Error("--name cannot be used when adding several certificates (names are derived with --name-from)")
+			os.Exit(verifierrors.ExitConfigError)
+		}
+		return runCertAddBatch(args, trustUntil, tags)
+	}
+
+	// Handle debugging proxy vs stdin vs file path
+	if certDebug != "" {
+		if certStdin || len(args) > 0 {
+			Error("Cannot combine --import-debug-proxy with --stdin or a file path")
+			os.Exit(verifierrors.ExitConfigError)
+		}
+
+		home, err := os.UserHomeDir()
+		if err != nil {
+			Error("Failed to find home directory: %v", err)
+			os.Exit(verifierrors.ExitConfigError)
+		}
+		certPath, err = locateDebugProxyCA(certDebug, home)
+		if err != nil {
+			Error("%v", err)
+			if certDebug == "burp" {
+				fmt.Fprintf(os.Stderr, "Export the CA from Burp (or download http://burpsuite through the proxy) and use 'verifi cert add <file>'\n")
+			}
+			os.Exit(verifierrors.ExitConfigError)
+		}
+
+		if certName == "" {
+			certName = strings.ToLower(certDebug)
+		}
+		if trustUntil.IsZero() {
+			trustUntil = time.Now().Add(defaultDebugProxyTTL)
+		}
+		tags = append(tags, "debug-proxy")
+	} else if certStdin {
 		// Read from stdin
 		if len(args) > 0 {
 			Error("Cannot specify both --stdin and a file path")
@@ -184,6 +309,32 @@ func runCertAdd(cmd *cobra.Command, args []string) error {
 			os.Exit(verifierrors.ExitConfigError)
 		}
 		certPath = args[0]
+
+		// Without --name, the name is derived as for several files
+		if certName == "" {
+			nameFrom := certNameSource()
+			var first *x509.Certificate
+			if nameFrom == certstore.NameFromCN {
+				certs, err := readCertFile(certPath, certPass)
+				if err != nil {
+					exitOnCertFileError(err, certPath, certPath)
+
+					Error("Failed to read certificate: %v", err)
+					os.Exit(verifierrors.ExitGeneralError)
+				}
+				first = certs[0]
+			}
+			certName = certstore.CertNameFor(certPath, first, nameFrom)
+			if certName == "" {
+				Error("Cannot derive a certificate name from %s (use --name)", certPath)
+				os.Exit(verifierrors.ExitConfigError)
+			}
+		}
+	}
+
+	if certName == "" {
+		Error("Certificate name required (use --name)")
+		os.Exit(verifierrors.ExitConfigError)
 	}
 
 	// Create store
@@ -210,17 +361,32 @@ func runCertAdd(cmd *cobra.Command, args []string) error {
 		Info("Adding certificate '%s' from %s...", certName, certPath)
 	}
 
-	if err := store.AddCert(ctx, certPath, certName, certForce); err != nil {
-		// Check for specific error types
-		if errors.Is(err, verifierrors.ErrCertExpired) {
-			Error("Certificate has expired")
-			fmt.Fprintf(os.Stderr, "Use --force to add expired certificates\n")
-			os.Exit(verifierrors.ExitCertError)
-		}
-		if errors.Is(err, verifierrors.ErrInvalidPEM) {
-			Error("Invalid PEM format")
-			os.Exit(verifierrors.ExitCertError)
-		}
+	opts := certstore.AddCertOptions{
+		Force:      certForce,
+		CAOnly:     certCAOnly,
+		Password:   certPass,
+		AllowLeaf:  certLeaf,
+		Tags:       tags,
+		Note:       certNote,
+		Owner:      certOwner,
+		TrustUntil: trustUntil,
+	}
+
+	// Adding under an existing name replaces it without an overlap
+	if existing, err := store.GetCertInfo(certName); err == nil {
+		Warning("Replacing existing certificate '%s' (%s)", certName, existing.Subject)
+		fmt.Fprintf(os.Stderr, "  To keep it trusted during a rotation, use 'verifi cert replace %s --with <file> --overlap 30d'\n", certName)
+	}
+
+	// Warn about certificates the store already trusts. Parse errors are
+	// reported by AddCertWithOptions below.
+	if certs, err := readCertFile(certPath, certPass); err == nil {
+		warnDuplicates(store, certs, certName)
+		warnMissingIssuers(store, certs)
+	}
+
+	if err := store.AddCertWithOptions(ctx, certPath, certName, opts); err != nil {
+		exitOnCertFileError(err, certName, certPath)
 
 		Error("Failed to add certificate: %v", err)
 		os.Exit(verifierrors.ExitGeneralError)
@@ -242,7 +408,25 @@ func runCertAdd(cmd *cobra.Command, args []string) error {
 			FieldIndented("Fingerprint", cert.Fingerprint, 2)
 			FieldIndented("Expires", cert.Expires.Format("2006-01-02 15:04:05 MST"), 2)
 			FieldIndented("Path", cert.Path, 2)
+			if len(cert.Tags) > 0 {
+				FieldIndented("Tags", strings.Join(cert.Tags, ", "), 2)
+			}
+			if !cert.TrustUntil.IsZero() {
+				FieldIndented("Trusted until", fmt.Sprintf("%s (%s)", cert.TrustUntil.Format("2006-01-02 15:04 MST"), formatRemaining(time.Until(cert.TrustUntil))), 2)
+			}
+			if cert.SourceFormat != "" && cert.SourceFormat != certstore.FormatPEM {
+				FieldIndented("Converted from", string(cert.SourceFormat), 2)
+			}
+			if len(cert.Certificates) > 0 {
+				FieldIndented("Certificates", fmt.Sprintf("%d", len(cert.Certificates)), 2)
+				printCertSummaries(cert.Certificates, 4)
+			}
 			EmptyLine()
+			if cert.Disabled {
+				Warning("Certificate '%s' is disabled and not in the combined bundle", certName)
+				fmt.Fprintf(os.Stderr, "Run 'verifi cert enable %s' to trust it\n", certName)
+				return nil
+			}
 			Info("Combined bundle rebuilt: %s", store.CombinedBundlePath())
 			return nil
 		}
@@ -267,12 +451,19 @@ func runCertList(cmd *cobra.Command, args []string) error {
 		os.Exit(verifierrors.ExitConfigError)
 	}
 
-	// Get certificates
-	certs, err := store.ListCerts()
+	// Get certificates from a consistent snapshot of the store
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	snap, err := store.Snapshot(ctx)
+	if err == nil {
+		err = snap.MetadataErr
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to list certificates: %v\n", err)
 		os.Exit(verifierrors.ExitGeneralError)
 	}
+	certs := snap.UserCerts()
 
 	// Filter expired if requested
 	if certExpired {
@@ -286,10 +477,21 @@ func runCertList(cmd *cobra.Command, args []string) error {
 		certs = filtered
 	}
 
+	// Filter by tag if requested
+	if len(certHasTags) > 0 {
+		filtered := []certstore.UserCertInfo{}
+		for _, cert := range certs {
+			if cert.HasTags(certHasTags) {
+				filtered = append(filtered, cert)
+			}
+		}
+		certs = filtered
+	}
+
 	// Handle no certificates
 	if len(certs) == 0 {
-		if certExpired {
-			Info("No expired certificates found")
+		if certExpired || len(certHasTags) > 0 {
+			Info("No matching certificates found")
 		} else {
 			Info("No user certificates in store")
 			EmptyLine()
@@ -310,7 +512,7 @@ func runCertList(cmd *cobra.Command, args []string) error {
 	// Table output
 	fmt.Printf("User Certificates (%d)\n\n", len(certs))
 
-	table := NewTable("NAME", "SUBJECT", "EXPIRES", "STATUS")
+	table := NewTable("NAME", "ROLE", "SUBJECT", "EXPIRES", "STATUS", "TAGS")
 	now := time.Now()
 	for _, cert := range certs {
 		// Truncate subject if too long
@@ -321,9 +523,26 @@ func runCertList(cmd *cobra.Command, args []string) error {
 		if now.After(cert.Expires) {
 			status = "EXPIRED"
 		}
+		for _, rev := range cert.Revisions {
+			if rev.Active(now) && status == "Valid" {
+				status = "Rotating"
+			}
+		}
+		if cert.TrustLapsed(now) {
+			status = "Lapsed"
+		} else if !cert.TrustUntil.IsZero() && status == "Valid" {
+			status = "Temporary (" + formatRemaining(cert.TrustUntil.Sub(now)) + ")"
+		}
+		if cert.Disabled {
+			status = "Disabled"
+		}
 
 		expiresStr := cert.Expires.Format("2006-01-02 15:04")
-		table.AddRow(cert.Name, subject, expiresStr, status)
+		tags := "-"
+		if len(cert.Tags) > 0 {
+			tags = strings.Join(cert.Tags, ",")
+		}
+		table.AddRow(cert.Name, certRole(cert.Role), subject, expiresStr, status, tags)
 	}
 	table.Print()
 
@@ -418,15 +637,73 @@ func runCertInspect(cmd *cobra.Command, args []string) error {
 	EmptyLine()
 
 	Field("Subject", info.Subject)
+	if info.Issuer != "" {
+		Field("Issuer", info.Issuer)
+	}
+	Field("Role", certRole(info.Role))
+	if info.Serial != "" {
+		Field("Serial", info.Serial)
+	}
 	Field("Fingerprint", info.Fingerprint)
+	if info.SPKIHash != "" {
+		Field("SPKI SHA256", info.SPKIHash)
+	}
+	if info.KeyAlgorithm != "" {
+		Field("Public key", fmt.Sprintf("%s %d bits", info.KeyAlgorithm, info.KeySize))
+	}
+	if info.SignatureAlgorithm != "" {
+		Field("Signature", info.SignatureAlgorithm)
+	}
+	if !info.NotBefore.IsZero() {
+		Field("Not before", info.NotBefore.Format("2006-01-02 15:04:05 MST"))
+	}
 	Field("Expires", info.Expires.Format("2006-01-02 15:04:05 MST"))
 	Field("Added", info.Added.Format("2006-01-02 15:04:05 MST"))
 	Field("Path", info.Path)
+	if info.SourceFormat != "" {
+		Field("Source format", string(info.SourceFormat))
+	}
+	if len(info.Tags) > 0 {
+		Field("Tags", strings.Join(info.Tags, ", "))
+	}
+	if info.Owner != "" {
+		Field("Owner", info.Owner)
+	}
+	if info.Note != "" {
+		Field("Note", info.Note)
+	}
+	if !info.TrustUntil.IsZero() {
+		Field("Trusted until", fmt.Sprintf("%s (%s)", info.TrustUntil.Format("2006-01-02 15:04 MST"), formatRemaining(time.Until(info.TrustUntil))))
+	}
+
+	if len(info.Certificates) > 0 {
+		EmptyLine()
+		Field("Certificates", fmt.Sprintf("%d", len(info.Certificates)))
+		printCertSummaries(info.Certificates, 2)
+	}
+
+	if len(info.Revisions) > 0 {
+		EmptyLine()
+		Field("Previous", fmt.Sprintf("%d", len(info.Revisions)))
+		for i, rev := range info.Revisions {
+			state := "retired"
+			if rev.Active(time.Now()) {
+				state = "trusted until " + rev.RetireAt.Format("2006-01-02 15:04 MST")
+			}
+			fmt.Printf("  %d. %s (%s)\n", i+1, rev.Subject, state)
+			FieldIndented("Fingerprint", rev.Fingerprint, 5)
+			FieldIndented("Replaced", rev.Replaced.Format("2006-01-02 15:04:05 MST"), 5)
+		}
+	}
 
 	// Check if expired
 	now := time.Now()
 	EmptyLine()
-	if now.After(info.Expires) {
+	if info.Disabled {
+		Field("Status", "Disabled (not in the combined bundle)")
+	} else if info.TrustLapsed(now) {
+		Field("Status", "Trust lapsed (not in the combined bundle)")
+	} else if now.After(info.Expires) {
 		Field("Status", "EXPIRED")
 	} else {
 		daysUntilExpiry := int(time.Until(info.Expires).Hours() / 24)
@@ -435,3 +712,117 @@ func runCertInspect(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// exitOnCertFileError reports the errors that adding or replacing a
+// certificate from a file can return and exits. It returns for other errors.
+func exitOnCertFileError(err error, name, certPath string) {
+	// Check for specific error types
+	if errors.Is(err, verifierrors.ErrCertExpired) {
+		Error("Certificate has expired")
+		fmt.Fprintf(os.Stderr, "Use --force to add expired certificates\n")
+		os.Exit(verifierrors.ExitCertError)
+	}
+	if errors.Is(err, verifierrors.ErrPrivateKey) {
+		Error("Refusing to add certificate '%s': input contains a private key", name)
+		fmt.Fprintf(os.Stderr, "The combined bundle is world-readable; remove the key and keep only the certificate(s)\n")
+		os.Exit(verifierrors.ExitCertError)
+	}
+	if errors.Is(err, verifierrors.ErrInvalidPEM) {
+		Error("Invalid certificate format (expected PEM, DER, PKCS#7 or PKCS#12)")
+		os.Exit(verifierrors.ExitCertError)
+	}
+	if errors.Is(err, verifierrors.ErrIncorrectPassword) {
+		Error("Incorrect or missing PKCS#12 password")
+		fmt.Fprintf(os.Stderr, "Use --password to provide the truststore password\n")
+		os.Exit(verifierrors.ExitCertError)
+	}
+	if errors.Is(err, verifierrors.ErrMetadataTooNew) {
+		Error("%v", err)
+		fmt.Fprintf(os.Stderr, "Update verifi to the latest version to modify this store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	if errors.Is(err, verifierrors.ErrLeafCert) {
+		Error("%v", errors.Unwrap(err))
+		fmt.Fprintf(os.Stderr, "Add the CA that issued it instead (try 'verifi cert fetch <host>'), or use --allow-leaf\n")
+		os.Exit(verifierrors.ExitCertError)
+	}
+	if errors.Is(err, verifierrors.ErrNoCACerts) {
+		Error("No CA certificates found in %s", certPath)
+		os.Exit(verifierrors.ExitCertError)
+	}
+}
+
+// readCertFile reads and parses every certificate in a file of any supported
+// format. Expired certificates are included.
+func readCertFile(path, password string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- user-specified certificate path is intentional
+	if err != nil {
+		return nil, err
+	}
+	pemData, _, err := certstore.ConvertToPEM(data, password)
+	if err != nil {
+		return nil, err
+	}
+	certs, _, err := certstore.ValidateCertChain(pemData, true)
+	return certs, err
+}
+
+// warnDuplicates prints a warning for each certificate that the store already
+// trusts through the Mozilla bundle or another user certificate. The entry
+// being added (name) is ignored so replacing it does not warn.
+func warnDuplicates(store *certstore.Store, certs []*x509.Certificate, name string) {
+	dups, err := store.FindDuplicates(certs, name)
+	if err != nil {
+		return
+	}
+
+	for _, dup := range dups {
+		for _, source := range dup.Sources {
+			if existing, ok := strings.CutPrefix(source, "user:"); ok {
+				Warning("%s is identical to existing user cert '%s'", dup.Subject, existing)
+			} else {
+				Warning("%s is already trusted via Mozilla", dup.Subject)
+			}
+		}
+	}
+}
+
+// warnMissingIssuers prints a warning for each intermediate whose issuer is
+// in neither the input, the Mozilla bundle nor the user store.
+func warnMissingIssuers(store *certstore.Store, certs []*x509.Certificate) {
+	missing, err := store.MissingIssuers(certs)
+	if err != nil {
+		return
+	}
+
+	for _, cert := range missing {
+		Warning("Issuer of intermediate %s is not trusted", cert.Subject.String())
+		fmt.Fprintf(os.Stderr, "  Missing issuer: %s\n", cert.Issuer.String())
+		fmt.Fprintf(os.Stderr, "  Add the issuing root CA as well, or tools will not be able to build a trusted chain\n")
+	}
+}
+
+// certRole returns a display label for a certificate role.
+func certRole(role certstore.CertRole) string {
+	if role == "" {
+		return "-"
+	}
+	return string(role)
+}
+
+// printCertSummaries prints each certificate of a multi-certificate entry.
+func printCertSummaries(certs []certstore.CertSummary, indent int) {
+	for i, c := range certs {
+		role := string(c.Role)
+		if role == "" {
+			// Entries added before roles were recorded
+			role = "leaf"
+			if c.IsCA {
+				role = "CA"
+			}
+		}
+		fmt.Printf("%s%d. %s (%s)\n", strings.Repeat(" ", indent), i+1, c.Subject, role)
+		FieldIndented("Fingerprint", c.Fingerprint, indent+3)
+		FieldIndented("Expires", c.Expires.Format("2006-01-02 15:04:05 MST"), indent+3)
+	}
+}
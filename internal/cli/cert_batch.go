@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// certFileExtensions are the file extensions picked up by cert add --dir.
+var certFileExtensions = map[string]bool{
+	".pem": true,
+	".crt": true,
+	".cer": true,
+	".der": true,
+	".p7b": true,
+	".p7c": true,
+	".p12": true,
+	".pfx": true,
+}
+
+// collectCertFiles expands the cert add arguments and --dir into the list
+// of files to add. Arguments that don't exist are treated as glob patterns.
+// Files that cannot be added (unmatched patterns, non-certificate files in
+// dir) are returned as skipped.
+func collectCertFiles(args []string, dir string) ([]string, []certstore.BatchSkipped, error) {
+	var files []string
+	var skipped []certstore.BatchSkipped
+
+	for _, arg := range args {
+		if _, err := os.Stat(arg); err == nil || !strings.ContainsAny(arg, "*?[") {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			skipped = append(skipped, certstore.BatchSkipped{File: arg, Reason: "no files match"})
+		}
+		files = append(files, matches...)
+	}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !certFileExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+				skipped = append(skipped, certstore.BatchSkipped{File: path, Reason: "not a certificate file extension"})
+				continue
+			}
+			files = append(files, path)
+		}
+	}
+
+	sort.Strings(files)
+	return files, skipped, nil
+}
+
+// certNameSource returns the --name-from value, exiting on an invalid one.
+func certNameSource() certstore.CertNameSource {
+	nameFrom := certstore.CertNameSource(certNameSrc)
+	if nameFrom != certstore.NameFromFile && nameFrom != certstore.NameFromCN {
+		Error("Invalid --name-from %q (expected file or cn)", certNameSrc)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	return nameFrom
+}
+
+// runCertAddBatch adds several certificate files in one transaction.
+func runCertAddBatch(args []string, trustUntil time.Time, tags []string) error {
+	nameFrom := certNameSource()
+
+	files, skipped, err := collectCertFiles(args, certDir)
+	if err != nil {
+		Error("%v", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	if len(files) == 0 {
+		Error("No certificate files found")
+		printBatchSkipped(skipped)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	opts := certstore.AddCertOptions{
+		Force:      certForce,
+		CAOnly:     certCAOnly,
+		Password:   certPass,
+		AllowLeaf:  certLeaf,
+		Tags:       tags,
+		Note:       certNote,
+		Owner:      certOwner,
+		TrustUntil: trustUntil,
+	}
+
+	Info("Adding %d certificate file(s)...", len(files))
+
+	result, err := store.AddCerts(ctx, files, opts, nameFrom)
+	if err != nil {
+		if errors.Is(err, verifierrors.ErrMetadataTooNew) {
+			Error("%v", err)
+			fmt.Fprintf(os.Stderr, "Update verifi to the latest version to modify this store\n")
+			os.Exit(verifierrors.ExitConfigError)
+		}
+		Error("Failed to add certificates, nothing was added: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+	skipped = append(skipped, result.Skipped...)
+
+	if len(result.Added) > 0 {
+		Success("Added %d certificate(s)", len(result.Added))
+		table := NewTable("NAME", "SUBJECT", "FILE")
+		for _, added := range result.Added {
+			table.AddRow(added.Name, TruncateString(added.Subject, 40), added.File)
+		}
+		table.Print()
+	} else {
+		Info("No certificates were added")
+	}
+
+	if len(skipped) > 0 {
+		EmptyLine()
+		printBatchSkipped(skipped)
+	}
+
+	if len(result.Added) > 0 {
+		EmptyLine()
+		Info("Combined bundle rebuilt: %s", store.CombinedBundlePath())
+	}
+
+	// Files that were already in the store are not a failure, so scripts can
+	// be re-run; any other skipped file fails the command
+	for _, skip := range skipped {
+		if !errors.Is(skip.Err, verifierrors.ErrCertExists) {
+			os.Exit(verifierrors.ExitCertError)
+		}
+	}
+
+	return nil
+}
+
+// printBatchSkipped lists skipped files and why they were skipped.
+func printBatchSkipped(skipped []certstore.BatchSkipped) {
+	if len(skipped) == 0 {
+		return
+	}
+	Warning("Skipped %d file(s):", len(skipped))
+	for _, skip := range skipped {
+		reason := skip.Reason
+		if errors.Is(skip.Err, verifierrors.ErrCertExpired) {
+			reason += " (use --force to add expired certificates)"
+		} else if errors.Is(skip.Err, verifierrors.ErrLeafCert) {
+			reason += " (use --ca-only or --allow-leaf)"
+		}
+		fmt.Printf("  - %s: %s\n", skip.File, reason)
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectCertFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pem", "b.CRT", "c.p12", "README.md"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	files, skipped, err := collectCertFiles(nil, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "a.pem"),
+		filepath.Join(dir, "b.CRT"),
+		filepath.Join(dir, "c.p12"),
+	}, files)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, filepath.Join(dir, "README.md"), skipped[0].File)
+
+	// Arguments that don't exist are expanded as glob patterns
+	files, skipped, err = collectCertFiles([]string{filepath.Join(dir, "*.pem"), filepath.Join(dir, "*.der")}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a.pem")}, files)
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "no files match", skipped[0].Reason)
+
+	// Plain paths are passed through, even when missing, so the error is reported
+	files, _, err = collectCertFiles([]string{filepath.Join(dir, "missing.pem")}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "missing.pem")}, files)
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// certDisableCmd represents the cert disable command.
+var certDisableCmd = &cobra.Command{
+	Use:   "disable <name>",
+	Short: "Temporarily remove a certificate from the trust set",
+	Long: `Exclude a user certificate from the combined bundle without deleting it.
+
+The certificate file stays in certs/user/ and its metadata (including tags,
+note and owner) is kept, so it can be restored with 'verifi cert enable'.
+The combined bundle is rebuilt immediately.
+
+Examples:
+  verifi cert disable corporate`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCertSetDisabled(args[0], true)
+	},
+	Annotations: mutates,
+}
+
+// certEnableCmd represents the cert enable command.
+var certEnableCmd = &cobra.Command{
+	Use:   "enable <name>",
+	Short: "Restore a disabled certificate to the trust set",
+	Long: `Add a disabled user certificate back to the combined bundle.
+
+The combined bundle is rebuilt immediately.
+
+Examples:
+  verifi cert enable corporate`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCertSetDisabled(args[0], false)
+	},
+	Annotations: mutates,
+}
+
+func init() {
+	certCmd.AddCommand(certDisableCmd)
+	certCmd.AddCommand(certEnableCmd)
+}
+
+func runCertSetDisabled(name string, disabled bool) error {
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	action := "enable"
+	if disabled {
+		action = "disable"
+	}
+
+	// Nothing to do if the certificate is already in the requested state
+	if info, err := store.GetCertInfo(name); err == nil && info.Disabled == disabled {
+		Info("Certificate '%s' is already %sd", name, action)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if disabled {
+		err = store.DisableCert(ctx, name)
+	} else {
+		err = store.EnableCert(ctx, name)
+	}
+	if err != nil {
+		if errors.Is(err, verifierrors.ErrCertNotFound) {
+			Error("Certificate '%s' not found", name)
+			fmt.Fprintf(os.Stderr, "Use 'verifi cert list' to see available certificates\n")
+			os.Exit(verifierrors.ExitCertError)
+		}
+		if errors.Is(err, verifierrors.ErrMetadataTooNew) {
+			Error("%v", err)
+			fmt.Fprintf(os.Stderr, "Update verifi to the latest version to modify this store\n")
+			os.Exit(verifierrors.ExitConfigError)
+		}
+
+		Error("Failed to %s certificate: %v", action, err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	Success("Certificate '%s' %sd", name, action)
+	EmptyLine()
+	Info("Combined bundle rebuilt: %s", store.CombinedBundlePath())
+
+	return nil
+}
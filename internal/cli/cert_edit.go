@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+var (
+	editTags       []string
+	editAddTags    []string
+	editRemoveTags []string
+	editNote       string
+	editOwner      string
+)
+
+// certEditCmd represents the cert edit command.
+var certEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Change the tags, note or owner of a certificate",
+	Long: `Change the labels of a user certificate.
+
+--tag replaces all tags (use --tag "" to clear them), while --add-tag and
+--remove-tag change individual tags. --note and --owner replace the current
+value; pass an empty string to clear it. Only the given flags are changed.
+
+Labels are stored in metadata only, so the combined bundle is not rebuilt.
+
+Examples:
+  verifi cert edit corp --add-tag vpn
+  verifi cert edit corp --tag corp --tag proxy
+  verifi cert edit corp --remove-tag legacy --owner secops@corp.com
+  verifi cert edit corp --note ""`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runCertEdit,
+	Annotations: mutates,
+}
+
+func init() {
+	certCmd.AddCommand(certEditCmd)
+
+	certEditCmd.Flags().StringSliceVar(&editTags, "tag", nil, "Replace all tags (repeatable)")
+	certEditCmd.Flags().StringSliceVar(&editAddTags, "add-tag", nil, "Add a tag (repeatable)")
+	certEditCmd.Flags().StringSliceVar(&editRemoveTags, "remove-tag", nil, "Remove a tag (repeatable)")
+	certEditCmd.Flags().StringVar(&editNote, "note", "", "Set the note")
+	certEditCmd.Flags().StringVar(&editOwner, "owner", "", "Set the owner")
+}
+
+func runCertEdit(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	edit := newCertLabelEdit(cmd)
+	if edit.Tags == nil && edit.AddTags == nil && edit.RemoveTags == nil && edit.Note == nil && edit.Owner == nil {
+		Error("Nothing to change")
+		fmt.Fprintf(os.Stderr, "Use --tag, --add-tag, --remove-tag, --note or --owner\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	info, err := store.EditCertLabels(ctx, name, edit)
+	if err != nil {
+		if errors.Is(err, verifierrors.ErrCertNotFound) {
+			Error("Certificate '%s' not found", name)
+			fmt.Fprintf(os.Stderr, "Use 'verifi cert list' to see available certificates\n")
+			os.Exit(verifierrors.ExitCertError)
+		}
+		if errors.Is(err, verifierrors.ErrMetadataTooNew) {
+			Error("%v", err)
+			fmt.Fprintf(os.Stderr, "Update verifi to the latest version to modify this store\n")
+			os.Exit(verifierrors.ExitConfigError)
+		}
+
+		Error("Failed to edit certificate: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	Success("Certificate '%s' updated", name)
+	FieldIndented("Tags", orDash(strings.Join(info.Tags, ", ")), 2)
+	FieldIndented("Owner", orDash(info.Owner), 2)
+	FieldIndented("Note", orDash(info.Note), 2)
+
+	return nil
+}
+
+// newCertLabelEdit builds the label edit from the flags that were set.
+func newCertLabelEdit(cmd *cobra.Command) certstore.CertLabelEdit {
+	var edit certstore.CertLabelEdit
+	flags := cmd.Flags()
+	if flags.Changed("tag") {
+		edit.Tags = append([]string{}, editTags...)
+	}
+	if flags.Changed("add-tag") {
+		edit.AddTags = editAddTags
+	}
+	if flags.Changed("remove-tag") {
+		edit.RemoveTags = editRemoveTags
+	}
+	if flags.Changed("note") {
+		edit.Note = &editNote
+	}
+	if flags.Changed("owner") {
+		edit.Owner = &editOwner
+	}
+	return edit
+}
+
+// orDash returns s, or "-" if s is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCertLabelEdit(t *testing.T) {
+	newCmd := func(t *testing.T, args ...string) *cobra.Command {
+		t.Helper()
+		// Fresh flags bound to the same variables as certEditCmd
+		cmd := &cobra.Command{Use: "edit"}
+		cmd.Flags().StringSliceVar(&editTags, "tag", nil, "")
+		cmd.Flags().StringSliceVar(&editAddTags, "add-tag", nil, "")
+		cmd.Flags().StringSliceVar(&editRemoveTags, "remove-tag", nil, "")
+		cmd.Flags().StringVar(&editNote, "note", "", "")
+		cmd.Flags().StringVar(&editOwner, "owner", "", "")
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	t.Run("only changed flags are set", func(t *testing.T) {
+		edit := newCertLabelEdit(newCmd(t, "--add-tag", "vpn", "--owner", ""))
+		assert.Nil(t, edit.Tags)
+		assert.Equal(t, []string{"vpn"}, edit.AddTags)
+		assert.Nil(t, edit.Note)
+		require.NotNil(t, edit.Owner)
+		assert.Equal(t, "", *edit.Owner)
+	})
+
+	t.Run("empty tag clears all tags", func(t *testing.T) {
+		edit := newCertLabelEdit(newCmd(t, "--tag", ""))
+		require.NotNil(t, edit.Tags)
+		assert.Empty(t, edit.Tags)
+	})
+}
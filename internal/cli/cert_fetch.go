@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+	"github.com/princespaghetti/verifi/internal/fetcher"
+)
+
+var (
+	fetchName   string
+	fetchSNI    string
+	fetchSelect string
+	fetchYes    bool
+	fetchForce  bool
+	fetchLeaf   bool
+)
+
+// certFetchCmd represents the cert fetch command.
+var certFetchCmd = &cobra.Command{
+	Use:   "fetch <host[:port]>",
+	Short: "Capture a server's CA certificate via a TLS handshake",
+	Long: `Connect to a server over TLS and show the certificate chain it presents.
+
+This is the quickest way to get the CA of a TLS-intercepting proxy: connect
+to any HTTPS host through the proxy and add the topmost CA from the chain.
+The server is NOT verified during the handshake, so check the fingerprint
+with your IT or security team before trusting it.
+
+By default, the chain is displayed and you are asked which certificate to
+add. Use --yes with --select for non-interactive use. --select accepts
+"root" (the topmost CA in the chain) or a 1-based position in the chain.
+
+Examples:
+  verifi cert fetch proxy.corp.com
+  verifi cert fetch internal.corp.com:8443 --name corp-proxy
+  verifi cert fetch 10.0.0.5:443 --sni registry.npmjs.org
+  verifi cert fetch proxy.corp.com --yes --select root --name proxy`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runCertFetch,
+	Annotations: mutates,
+}
+
+func init() {
+	certCmd.AddCommand(certFetchCmd)
+
+	certFetchCmd.Flags().StringVar(&fetchName, "name", "", "Certificate name (defaults to the host name)")
+	certFetchCmd.Flags().StringVar(&fetchSNI, "sni", "", "Override the TLS server name (SNI)")
+	certFetchCmd.Flags().StringVar(&fetchSelect, "select", "root", "Certificate to add: \"root\" or a position in the chain")
+	certFetchCmd.Flags().BoolVarP(&fetchYes, "yes", "y", false, "Add the selected certificate without prompting")
+	certFetchCmd.Flags().BoolVar(&fetchForce, "force", false, "Force add even if expired")
+	certFetchCmd.Flags().BoolVar(&fetchLeaf, "allow-leaf", false, "Allow adding the server's leaf certificate")
+}
+
+func runCertFetch(cmd *cobra.Command, args []string) error {
+	addr, host, err := fetcher.ParseTarget(args[0])
+	if err != nil {
+		Error("Invalid target: %v", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Fetch chain with timeout. The timeout covers only the connection, not
+	// the prompts below.
+	fetchCtx, cancelFetch := context.WithTimeout(context.Background(), 30*time.Second)
+	Info("Connecting to %s...", addr)
+	chain, err := fetcher.FetchServerChain(fetchCtx, addr, fetchSNI)
+	cancelFetch()
+	if err != nil {
+		Error("Failed to fetch certificate chain: %v", err)
+		os.Exit(verifierrors.ExitNetworkError)
+	}
+
+	EmptyLine()
+	printServerChain(chain)
+
+	// Pick the certificate to add
+	defaultIndex, err := selectChainCert(chain, fetchSelect)
+	if err != nil {
+		if fetchYes {
+			Error("%v", err)
+			os.Exit(verifierrors.ExitCertError)
+		}
+		Warning("%v", err)
+		defaultIndex = len(chain) - 1
+	}
+
+	index := defaultIndex
+	if !fetchYes {
+		index, err = promptChainSelection(chain, defaultIndex)
+		if err != nil {
+			Error("%v", err)
+			os.Exit(verifierrors.ExitConfigError)
+		}
+	}
+
+	selected := chain[index]
+	if certstore.ClassifyCert(selected) == certstore.RoleLeaf && !fetchLeaf {
+		Error("Certificate %d is a leaf (server) certificate, not a CA", index+1)
+		fmt.Fprintf(os.Stderr, "Select a CA from the chain, or use --allow-leaf\n")
+		os.Exit(verifierrors.ExitCertError)
+	}
+	if !certstore.IsSelfSigned(selected) {
+		Warning("Certificate %d is not self-signed; some tools (e.g. OpenSSL) also need the root that issued it", index+1)
+	}
+
+	name := fetchName
+	if name == "" {
+		name = host
+	}
+	warnDuplicates(store, []*x509.Certificate{selected}, name)
+	warnMissingIssuers(store, []*x509.Certificate{selected})
+
+	if !fetchYes && !ConfirmPrompt(fmt.Sprintf("Add certificate %d as '%s'?", index+1, name)) {
+		Info("Aborted. No certificate was added.")
+		return nil
+	}
+
+	// Write the selected certificate to a temp file and add it through the normal path
+	tempFile, err := os.CreateTemp("", "verifi-fetch-*.pem")
+	if err != nil {
+		Error("Failed to create temporary file: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+	tempPath := tempFile.Name()
+	defer func() { _ = os.Remove(tempPath) }()
+
+	if _, err := tempFile.Write(certstore.EncodeCertsPEM([]*x509.Certificate{selected})); err != nil {
+		_ = tempFile.Close()
+		Error("Failed to write certificate data: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+	if err := tempFile.Close(); err != nil {
+		Error("Failed to close temporary file: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	// Add certificate with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := certstore.AddCertOptions{Force: fetchForce, AllowLeaf: fetchLeaf}
+	if err := store.AddCertWithOptions(ctx, tempPath, name, opts); err != nil {
+		if errors.Is(err, verifierrors.ErrCertExpired) {
+			Error("Certificate has expired")
+			fmt.Fprintf(os.Stderr, "Use --force to add expired certificates\n")
+			os.Exit(verifierrors.ExitCertError)
+		}
+
+		Error("Failed to add certificate: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	EmptyLine()
+	Success("Certificate '%s' added successfully", name)
+	FieldIndented("Subject", selected.Subject.String(), 2)
+	FieldIndented("Fingerprint", certstore.Fingerprint(selected), 2)
+	EmptyLine()
+	Info("Combined bundle rebuilt: %s", store.CombinedBundlePath())
+
+	return nil
+}
+
+// printServerChain prints the certificates presented by a server.
+func printServerChain(chain []*x509.Certificate) {
+	fmt.Printf("Presented chain (%d)\n\n", len(chain))
+	for i, cert := range chain {
+		fmt.Printf("  %d. %s [%s]\n", i+1, cert.Subject.String(), certstore.ClassifyCert(cert))
+		FieldIndented("Issuer", cert.Issuer.String(), 5)
+		FieldIndented("Fingerprint", certstore.Fingerprint(cert), 5)
+		FieldIndented("Expires", cert.NotAfter.Format("2006-01-02 15:04:05 MST"), 5)
+	}
+	EmptyLine()
+}
+
+// selectChainCert resolves a --select value to an index into chain.
+// "root" selects the topmost CA certificate; a number selects by 1-based position.
+func selectChainCert(chain []*x509.Certificate, sel string) (int, error) {
+	if sel == "" || sel == "root" {
+		for i := len(chain) - 1; i >= 0; i-- {
+			if chain[i].IsCA {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("server presented no CA certificates; use --select <n> to pick one explicitly")
+	}
+
+	n, err := strconv.Atoi(sel)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --select value %q (expected \"root\" or a number)", sel)
+	}
+	if n < 1 || n > len(chain) {
+		return 0, fmt.Errorf("--select %d is out of range (chain has %d certificates)", n, len(chain))
+	}
+	return n - 1, nil
+}
+
+// promptChainSelection asks which certificate to add, defaulting to defaultIndex.
+func promptChainSelection(chain []*x509.Certificate, defaultIndex int) (int, error) {
+	fmt.Printf("Select certificate to add [%d]: ", defaultIndex+1)
+	var response string
+	_, _ = fmt.Scanln(&response) // Ignore error, an empty answer accepts the default
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return defaultIndex, nil
+	}
+	return selectChainCert(chain, response)
+}
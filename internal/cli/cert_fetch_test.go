@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	"github.com/princespaghetti/verifi/internal/fetcher"
+)
+
+func TestCertFetchCmd_Flags(t *testing.T) {
+	for _, name := range []string{"name", "sni", "select", "yes", "force", "allow-leaf"} {
+		assert.NotNil(t, certFetchCmd.Flags().Lookup(name), "--%s flag not found", name)
+	}
+	assert.Equal(t, "root", certFetchCmd.Flags().Lookup("select").DefValue)
+}
+
+func TestSelectChainCert(t *testing.T) {
+	leaf := &x509.Certificate{IsCA: false}
+	intermediate := &x509.Certificate{IsCA: true}
+	root := &x509.Certificate{IsCA: true}
+
+	tests := []struct {
+		name    string
+		chain   []*x509.Certificate
+		sel     string
+		want    int
+		wantErr bool
+	}{
+		{name: "root picks topmost CA", chain: []*x509.Certificate{leaf, intermediate, root}, sel: "root", want: 2},
+		{name: "root without root in chain", chain: []*x509.Certificate{leaf, intermediate}, sel: "root", want: 1},
+		{name: "empty defaults to root", chain: []*x509.Certificate{leaf, intermediate}, sel: "", want: 1},
+		{name: "no CA certificates", chain: []*x509.Certificate{leaf}, sel: "root", wantErr: true},
+		{name: "explicit position", chain: []*x509.Certificate{leaf, intermediate, root}, sel: "1", want: 0},
+		{name: "out of range", chain: []*x509.Certificate{leaf}, sel: "2", wantErr: true},
+		{name: "not a number", chain: []*x509.Certificate{leaf}, sel: "top", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectChainCert(tt.chain, tt.sel)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCertFetch_AddsServerCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	store, _ := initTestStore(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addr, _, err := fetcher.ParseTarget(server.URL)
+	require.NoError(t, err)
+
+	chain, err := fetcher.FetchServerChain(ctx, addr, "")
+	require.NoError(t, err)
+
+	index, err := selectChainCert(chain, "root")
+	require.NoError(t, err)
+
+	certPath := filepath.Join(t.TempDir(), "fetched.pem")
+	require.NoError(t, os.WriteFile(certPath, certstore.EncodeCertsPEM([]*x509.Certificate{chain[index]}), 0644))
+
+	require.NoError(t, store.AddCert(ctx, certPath, "test-server", false))
+
+	info, err := store.GetCertInfo("test-server")
+	require.NoError(t, err)
+	assert.Equal(t, certstore.Fingerprint(server.Certificate()), info.Fingerprint)
+	assert.True(t, strings.HasPrefix(info.Fingerprint, "sha256:"))
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+var (
+	replaceWith     string
+	replaceOverlap  string
+	replaceForce    bool
+	replaceCAOnly   bool
+	replacePassword string
+	replaceLeaf     bool
+)
+
+// certRenameCmd represents the cert rename command.
+var certRenameCmd = &cobra.Command{
+	Use:   "rename <old-name> <new-name>",
+	Short: "Rename a certificate",
+	Long: `Rename a user certificate.
+
+The certificate file and its metadata entry are moved together under the
+store lock, and the combined bundle is rebuilt so its comments show the new
+name. Tags, note, owner and history are kept.
+
+Examples:
+  verifi cert rename proxy corp-proxy`,
+	Args:        cobra.ExactArgs(2),
+	RunE:        runCertRename,
+	Annotations: mutates,
+}
+
+// certReplaceCmd represents the cert replace command.
+var certReplaceCmd = &cobra.Command{
+	Use:   "replace <name> --with <path>",
+	Short: "Rotate a certificate, optionally keeping the old one for a while",
+	Long: `Replace a user certificate with a new file, e.g. when a corporate CA rotates.
+
+The new certificate takes over the name, tags, note and owner. The old
+certificate is recorded in the entry's history. With --overlap, the old
+certificate stays in the combined bundle next to the new one until the
+overlap has passed, so clients using either keep working; it is then
+retired automatically the next time verifi runs. Without --overlap the old
+certificate is dropped immediately.
+
+Durations accept d (days) and w (weeks) as well as h, m and s.
+
+Examples:
+  verifi cert replace corp --with corp-root-2026.pem --overlap 30d
+  verifi cert replace proxy --with new-proxy.pem`,
+	Args:        cobra.ExactArgs(1),
+	RunE:        runCertReplace,
+	Annotations: mutates,
+}
+
+func init() {
+	certCmd.AddCommand(certRenameCmd)
+	certCmd.AddCommand(certReplaceCmd)
+
+	certReplaceCmd.Flags().StringVar(&replaceWith, "with", "", "Path to the new certificate (required)")
+	certReplaceCmd.Flags().StringVar(&replaceOverlap, "overlap", "0d", "How long to keep trusting the old certificate (e.g. 30d)")
+	certReplaceCmd.Flags().BoolVar(&replaceForce, "force", false, "Force replace even if the new certificate is expired")
+	certReplaceCmd.Flags().BoolVar(&replaceCAOnly, "ca-only", false, "Import only CA certificates from a chain")
+	certReplaceCmd.Flags().StringVar(&replacePassword, "password", "", "Password for PKCS#12 files")
+	certReplaceCmd.Flags().BoolVar(&replaceLeaf, "allow-leaf", false, "Allow leaf (server) certificates")
+	_ = certReplaceCmd.MarkFlagRequired("with") // Ignore error - setup failure would be caught at runtime
+}
+
+func runCertRename(cmd *cobra.Command, args []string) error {
+	oldName, newName := args[0], args[1]
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := store.RenameCert(ctx, oldName, newName); err != nil {
+		if errors.Is(err, verifierrors.ErrCertNotFound) {
+			Error("Certificate '%s' not found", oldName)
+			fmt.Fprintf(os.Stderr, "Use 'verifi cert list' to see available certificates\n")
+			os.Exit(verifierrors.ExitCertError)
+		}
+		if errors.Is(err, verifierrors.ErrCertExists) {
+			Error("Certificate '%s' already exists", newName)
+			os.Exit(verifierrors.ExitCertError)
+		}
+		if errors.Is(err, verifierrors.ErrMetadataTooNew) {
+			Error("%v", err)
+			fmt.Fprintf(os.Stderr, "Update verifi to the latest version to modify this store\n")
+			os.Exit(verifierrors.ExitConfigError)
+		}
+
+		Error("Failed to rename certificate: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	Success("Certificate '%s' renamed to '%s'", oldName, newName)
+	EmptyLine()
+	Info("Combined bundle rebuilt: %s", store.CombinedBundlePath())
+
+	return nil
+}
+
+func runCertReplace(cmd *cobra.Command, args []string) error {
+	name := args[0]
+
+	overlap, err := certstore.ParseDuration(replaceOverlap)
+	if err != nil {
+		Error("Invalid --overlap: %v", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	opts := certstore.AddCertOptions{
+		Force:     replaceForce,
+		CAOnly:    replaceCAOnly,
+		Password:  replacePassword,
+		AllowLeaf: replaceLeaf,
+	}
+
+	// Parse errors are reported by ReplaceCert below
+	if certs, err := readCertFile(replaceWith, replacePassword); err == nil {
+		warnMissingIssuers(store, certs)
+	}
+
+	Info("Replacing certificate '%s' with %s...", name, replaceWith)
+
+	if err := store.ReplaceCert(ctx, name, replaceWith, opts, overlap); err != nil {
+		exitOnCertFileError(err, name, replaceWith)
+		if errors.Is(err, verifierrors.ErrCertNotFound) {
+			Error("Certificate '%s' not found", name)
+			fmt.Fprintf(os.Stderr, "Use 'verifi cert add' to add a new certificate\n")
+			os.Exit(verifierrors.ExitCertError)
+		}
+
+		Error("Failed to replace certificate: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	info, err := store.GetCertInfo(name)
+	if err != nil {
+		Success("Certificate '%s' replaced", name)
+		return nil
+	}
+
+	Success("Certificate '%s' replaced", name)
+	FieldIndented("Subject", info.Subject, 2)
+	FieldIndented("Fingerprint", info.Fingerprint, 2)
+	FieldIndented("Expires", info.Expires.Format("2006-01-02 15:04:05 MST"), 2)
+	if len(info.Revisions) > 0 {
+		rev := info.Revisions[len(info.Revisions)-1]
+		if rev.Active(time.Now()) {
+			FieldIndented("Old certificate", fmt.Sprintf("trusted until %s", rev.RetireAt.Format("2006-01-02 15:04 MST")), 2)
+		} else {
+			FieldIndented("Old certificate", "removed from the bundle", 2)
+		}
+	}
+	EmptyLine()
+	Info("Combined bundle rebuilt: %s", store.CombinedBundlePath())
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertReplaceCmd_Flags(t *testing.T) {
+	for _, name := range []string{"with", "overlap", "force", "ca-only", "password", "allow-leaf"} {
+		assert.NotNil(t, certReplaceCmd.Flags().Lookup(name), "--%s flag not found", name)
+	}
+	assert.Equal(t, "0d", certReplaceCmd.Flags().Lookup("overlap").DefValue)
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// defaultExpiryWindow is how far ahead status, doctor and check expiry look
+// for certificates that are about to expire.
+const defaultExpiryWindow = 30 * 24 * time.Hour
+
+var (
+	checkWithin         string
+	checkIncludeMozilla bool
+	checkFormat         string
+)
+
+// checkCmd represents the check command.
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Run monitoring checks suitable for CI",
+	Long: `Run monitoring checks against the certificate store.
+
+Checks exit with a distinct status when they find something, so they can be
+used in CI jobs and scheduled monitoring.`,
+}
+
+// checkExpiryCmd represents the check expiry command.
+var checkExpiryCmd = &cobra.Command{
+	Use:   "expiry",
+	Short: "Report certificates that expire soon",
+	Long: `Report trusted certificates that have expired or expire within a window.
+
+All certificates of every enabled user certificate entry are checked. Use
+--include-mozilla to also check the roots in the Mozilla bundle; note that
+Mozilla routinely ships roots close to their expiry, which are replaced by
+'verifi bundle update'.
+
+Output formats:
+  text   - a table (default)
+  json   - machine-readable output
+  github - GitHub Actions workflow annotations (errors for expired
+           certificates, warnings for expiring ones)
+
+Exit codes:
+  0 - No certificate expires within the window
+  2 - Invalid arguments or store not initialized
+  5 - At least one certificate has expired or expires within the window
+
+Examples:
+  verifi check expiry
+  verifi check expiry --within 90d --include-mozilla
+  verifi check expiry --within 2w --format json
+  verifi check expiry --format github`,
+	Args: cobra.NoArgs,
+	RunE: runCheckExpiry,
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.AddCommand(checkExpiryCmd)
+
+	checkExpiryCmd.Flags().StringVar(&checkWithin, "within", "30d", "Window to check (e.g. 30d, 2w, 72h)")
+	checkExpiryCmd.Flags().BoolVar(&checkIncludeMozilla, "include-mozilla", false, "Also check the roots in the Mozilla bundle")
+	checkExpiryCmd.Flags().StringVar(&checkFormat, "format", "text", "Output format: text, json or github")
+}
+
+// ExpiryOutput represents the result of the check expiry command.
+type ExpiryOutput struct {
+	Checked        time.Time     `json:"checked"`
+	Within         string        `json:"within"`
+	IncludeMozilla bool          `json:"include_mozilla"`
+	Expiring       []ExpiryEntry `json:"expiring"`
+}
+
+// ExpiryEntry describes a certificate in check expiry output.
+type ExpiryEntry struct {
+	certstore.ExpiringCert
+	DaysLeft int  `json:"days_left"`
+	Expired  bool `json:"expired"`
+}
+
+func runCheckExpiry(cmd *cobra.Command, args []string) error {
+	within, err := certstore.ParseDuration(checkWithin)
+	if err != nil {
+		Error("Invalid --within: %v", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	switch checkFormat {
+	case "text", "json", "github":
+	default:
+		Error("Invalid --format %q (expected text, json or github)", checkFormat)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	now := time.Now()
+	certs, err := store.ExpiringCerts(now, within, checkIncludeMozilla)
+	if err != nil {
+		Error("Failed to check certificates: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	output := ExpiryOutput{
+		Checked:        now,
+		Within:         checkWithin,
+		IncludeMozilla: checkIncludeMozilla,
+		Expiring:       make([]ExpiryEntry, 0, len(certs)),
+	}
+	for _, cert := range certs {
+		output.Expiring = append(output.Expiring, ExpiryEntry{
+			ExpiringCert: cert,
+			DaysLeft:     daysUntil(now, cert.Expires),
+			Expired:      !now.Before(cert.Expires),
+		})
+	}
+
+	switch checkFormat {
+	case "json":
+		if err := JSON(output); err != nil {
+			Error("Failed to encode JSON: %v", err)
+			os.Exit(verifierrors.ExitGeneralError)
+		}
+	case "github":
+		printExpiryAnnotations(output)
+	default:
+		printExpiryHuman(output)
+	}
+
+	if len(output.Expiring) > 0 {
+		os.Exit(verifierrors.ExitExpiryWarning)
+	}
+
+	return nil
+}
+
+// daysUntil returns the number of whole days from now until t, negative
+// once t has passed.
+func daysUntil(now, t time.Time) int {
+	return int(t.Sub(now).Hours() / 24)
+}
+
+// describeExpiry renders the time until an expiry, e.g. "expires in 12 days"
+// or "expired 3 days ago".
+func describeExpiry(now, expires time.Time) string {
+	days := daysUntil(now, expires)
+	switch {
+	case !now.Before(expires):
+		if days == 0 {
+			return "expired today"
+		}
+		return fmt.Sprintf("expired %d days ago", -days)
+	case days == 0:
+		return "expires within a day"
+	case days == 1:
+		return "expires in 1 day"
+	default:
+		return fmt.Sprintf("expires in %d days", days)
+	}
+}
+
+// printExpiryHuman prints check expiry output as a table.
+func printExpiryHuman(output ExpiryOutput) {
+	if len(output.Expiring) == 0 {
+		Success("No certificates expire within %s", output.Within)
+		return
+	}
+
+	fmt.Printf("Certificates expiring within %s (%d)\n\n", output.Within, len(output.Expiring))
+
+	table := NewTable("SOURCE", "SUBJECT", "EXPIRES", "STATUS")
+	for _, entry := range output.Expiring {
+		table.AddRow(entry.Source, TruncateString(entry.Subject, 40), entry.Expires.Format("2006-01-02"), describeExpiry(output.Checked, entry.Expires))
+	}
+	table.Print()
+}
+
+// printExpiryAnnotations prints check expiry output as GitHub Actions
+// workflow commands, which the runner turns into annotations.
+func printExpiryAnnotations(output ExpiryOutput) {
+	for _, entry := range output.Expiring {
+		level, title := "warning", "Certificate expiring"
+		if entry.Expired {
+			level, title = "error", "Certificate expired"
+		}
+		message := fmt.Sprintf("%s (%s) %s on %s", entry.Subject, entry.Source, describeExpiry(output.Checked, entry.Expires), entry.Expires.Format("2006-01-02"))
+		fmt.Printf("::%s title=%s::%s\n", level, escapeAnnotationProperty(title), escapeAnnotationData(message))
+	}
+	if len(output.Expiring) == 0 {
+		fmt.Printf("::notice title=Certificate expiry::No certificates expire within %s\n", escapeAnnotationData(output.Within))
+	}
+}
+
+// escapeAnnotationData escapes the message of a GitHub workflow command.
+func escapeAnnotationData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeAnnotationProperty escapes a property value of a GitHub workflow
+// command, which additionally may not contain ':' or ','.
+func escapeAnnotationProperty(s string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeAnnotationData(s))
+}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "expires in 12 days", describeExpiry(now, now.Add(12*24*time.Hour+time.Hour)))
+	assert.Equal(t, "expires in 1 day", describeExpiry(now, now.Add(30*time.Hour)))
+	assert.Equal(t, "expires within a day", describeExpiry(now, now.Add(time.Hour)))
+	assert.Equal(t, "expired today", describeExpiry(now, now.Add(-time.Hour)))
+	assert.Equal(t, "expired 3 days ago", describeExpiry(now, now.Add(-3*24*time.Hour)))
+}
+
+func TestEscapeAnnotation(t *testing.T) {
+	assert.Equal(t, "50%25 done%0Anext", escapeAnnotationData("50% done\nnext"))
+	assert.Equal(t, "CN=a%2C O=b%3A c", escapeAnnotationProperty("CN=a, O=b: c"))
+}
@@ -2,10 +2,11 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -24,7 +25,9 @@ var cleanCmd = &cobra.Command{
 	Short: "Clean up temporary files or remove the entire store",
 	Long: `Clean up temporary files from the certificate store or remove it entirely.
 
-By default, removes only temporary files (*.tmp, *.lock).
+By default, removes only stale temporary files: *.tmp files older than ten
+minutes and *.lock files whose locked file no longer exists. Files still in
+use by another verifi command are left alone.
 
 Use --full to remove the entire certificate store (requires confirmation).
 Use --full --force to skip confirmation (dangerous).
@@ -58,70 +61,38 @@ func runClean(cmd *cobra.Command, args []string) error {
 	}
 
 	// Temp file cleanup
-	return runTempCleanup(basePath)
+	return runTempCleanup(store)
 }
 
-func runTempCleanup(basePath string) error {
-	Info("Cleaning temporary files...")
-
-	// Find all temp and lock files
-	tempPatterns := []string{"*.tmp", "*.lock"}
-	foundFiles := []string{}
-
-	for _, pattern := range tempPatterns {
-		matches, err := filepath.Glob(filepath.Join(basePath, "**", pattern))
-		if err != nil {
-			continue
-		}
-		foundFiles = append(foundFiles, matches...)
+func runTempCleanup(store *certstore.Store) error {
+	if !store.IsInitialized() {
+		Info("Certificate store does not exist")
+		return nil
 	}
 
-	// Also check in the base directory
-	for _, pattern := range tempPatterns {
-		matches, err := filepath.Glob(filepath.Join(basePath, pattern))
-		if err != nil {
-			continue
-		}
-		foundFiles = append(foundFiles, matches...)
-	}
+	Info("Cleaning temporary files...")
 
-	// Also check in certs directory
-	for _, pattern := range tempPatterns {
-		matches, err := filepath.Glob(filepath.Join(basePath, "certs", pattern))
-		if err != nil {
-			continue
-		}
-		foundFiles = append(foundFiles, matches...)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	// Also check in bundles directory
-	for _, pattern := range tempPatterns {
-		matches, err := filepath.Glob(filepath.Join(basePath, "certs", "bundles", pattern))
-		if err != nil {
-			continue
-		}
-		foundFiles = append(foundFiles, matches...)
+	removed, err := store.CleanTempFiles(ctx)
+	if err != nil {
+		Error("Failed to clean temporary files: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
 	}
 
-	if len(foundFiles) == 0 {
+	if len(removed) == 0 {
 		EmptyLine()
-		Success("No temporary files found")
+		Success("No stale temporary files found")
 		return nil
 	}
 
-	// Remove temp files
-	removedCount := 0
-	for _, file := range foundFiles {
-		if err := os.Remove(file); err != nil {
-			Warning("Failed to remove %s: %v", file, err)
-		} else {
-			removedCount++
-			fmt.Printf("  Removed: %s\n", filepath.Base(file))
-		}
+	for _, file := range removed {
+		fmt.Printf("  Removed: %s\n", file)
 	}
 
 	EmptyLine()
-	Success("Removed %d temporary file(s)", removedCount)
+	Success("Removed %d temporary file(s)", len(removed))
 	return nil
 }
 
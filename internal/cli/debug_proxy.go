@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultDebugProxyTTL is how long a debugging proxy CA is trusted when it is
+// imported without --ttl or --until.
+const defaultDebugProxyTTL = 8 * time.Hour
+
+// debugProxyCAPaths lists, per tool, where its CA certificate is found
+// relative to the user's home directory, in order of preference.
+var debugProxyCAPaths = map[string][]string{
+	"mitmproxy": {
+		".mitmproxy/mitmproxy-ca-cert.pem",
+	},
+	"charles": {
+		"Library/Application Support/Charles/ca/charles-proxy-ssl-proxying-certificate.pem",
+		".charles/ca/charles-proxy-ssl-proxying-certificate.pem",
+	},
+	// Burp does not keep its CA on disk; these are where it is usually
+	// exported to (http://burpsuite serves it as cacert.der).
+	"burp": {
+		".BurpSuite/cacert.der",
+		"Downloads/cacert.der",
+		"cacert.der",
+	},
+}
+
+// debugProxyTools returns the supported --import-debug-proxy values.
+func debugProxyTools() []string {
+	tools := make([]string, 0, len(debugProxyCAPaths))
+	for tool := range debugProxyCAPaths {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// locateDebugProxyCA returns the path of a debugging proxy's CA certificate
+// in home, trying the tool's well-known locations in order.
+func locateDebugProxyCA(tool, home string) (string, error) {
+	candidates, ok := debugProxyCAPaths[strings.ToLower(tool)]
+	if !ok {
+		return "", fmt.Errorf("unknown debugging proxy %q (expected %s)", tool, strings.Join(debugProxyTools(), ", "))
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(home, filepath.FromSlash(candidate))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+
+	tried := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		tried[i] = filepath.Join("~", filepath.FromSlash(candidate))
+	}
+	return "", fmt.Errorf("no %s CA certificate found (looked in %s)", tool, strings.Join(tried, ", "))
+}
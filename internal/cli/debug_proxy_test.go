@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocateDebugProxyCA(t *testing.T) {
+	home := t.TempDir()
+
+	_, err := locateDebugProxyCA("mitmproxy", home)
+	assert.Error(t, err, "missing CA should be reported")
+
+	caPath := filepath.Join(home, ".mitmproxy", "mitmproxy-ca-cert.pem")
+	require.NoError(t, os.MkdirAll(filepath.Dir(caPath), 0755))
+	require.NoError(t, os.WriteFile(caPath, []byte("ca"), 0644))
+
+	got, err := locateDebugProxyCA("MITMProxy", home)
+	require.NoError(t, err)
+	assert.Equal(t, caPath, got)
+
+	// Later locations are tried when earlier ones are missing
+	burpPath := filepath.Join(home, "Downloads", "cacert.der")
+	require.NoError(t, os.MkdirAll(filepath.Dir(burpPath), 0755))
+	require.NoError(t, os.WriteFile(burpPath, []byte("ca"), 0644))
+
+	got, err = locateDebugProxyCA("burp", home)
+	require.NoError(t, err)
+	assert.Equal(t, burpPath, got)
+
+	_, err = locateDebugProxyCA("fiddler", home)
+	assert.ErrorContains(t, err, "unknown debugging proxy")
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -34,7 +35,9 @@ Checks performed:
   - Metadata file is valid JSON with correct schema
   - Mozilla bundle exists and contains valid PEM certificates
   - Combined bundle exists and contains valid PEM certificates
-  - User certificates exist and are valid (not expired)
+  - User certificates exist and are valid (not expired or expiring soon)
+  - User certificate files and the combined bundle hold only certificates
+    (no private keys or other PEM blocks)
   - env.sh file exists and contains correct environment variables
   - File permissions allow read access
 
@@ -92,16 +95,25 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		os.Exit(verifierrors.ExitConfigError)
 	}
 
-	// Run all diagnostic checks
+	// Read the store once, so the checks see one consistent state even if
+	// another verifi is changing it
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	snap, err := store.Snapshot(ctx)
+	if err != nil {
+		Error("Failed to read the certificate store: %v", err)
+		os.Exit(verifierrors.ExitGeneralError)
+	}
+
+	// Run all diagnostic checks
 	results := []CheckResult{
 		checkStoreStructure(store),
-		checkMetadata(store),
-		checkMozillaBundle(store),
-		checkCombinedBundle(store),
-		checkUserCertificates(ctx, store),
+		checkMetadata(snap),
+		checkMozillaBundle(snap),
+		checkCombinedBundle(snap),
+		checkUserCertificates(snap),
+		checkBundleContents(store, snap),
 		checkEnvFile(store),
 		checkFilePermissions(store),
 	}
@@ -231,25 +243,29 @@ func checkStoreStructure(store *certstore.Store) CheckResult {
 }
 
 // checkMetadata verifies the metadata file is valid.
-func checkMetadata(store *certstore.Store) CheckResult {
+func checkMetadata(snap *certstore.Snapshot) CheckResult {
 	result := CheckResult{
 		Name:   "Metadata integrity",
 		Status: "pass",
 	}
 
-	metadata, err := store.GetMetadata()
-	if err != nil {
+	metadata := snap.Metadata
+	if snap.MetadataErr != nil {
 		result.Status = "fail"
-		result.Issues = append(result.Issues, fmt.Sprintf("Cannot read metadata: %v", err))
+		result.Issues = append(result.Issues, fmt.Sprintf("Cannot read metadata: %v", snap.MetadataErr))
 		result.Suggestions = append(result.Suggestions, "Run 'verifi init --force' to recreate metadata")
 		return result
 	}
 
 	// Check schema version
-	if metadata.Version != "1" {
+	if metadata.IsNewerSchema() {
 		result.Status = "fail"
-		result.Issues = append(result.Issues, fmt.Sprintf("Unknown metadata schema version: %s", metadata.Version))
-		result.Suggestions = append(result.Suggestions, "Update verifi to the latest version")
+		result.Issues = append(result.Issues, fmt.Sprintf("Metadata schema version %s is newer than this verifi supports (written by verifi %s)", metadata.Version, metadata.WrittenBy))
+		result.Suggestions = append(result.Suggestions, "Update verifi to the latest version; changes to the store are refused until then")
+	} else if from := metadata.MigratedFrom(); from != 0 {
+		result.Status = "warn"
+		result.Issues = append(result.Issues, fmt.Sprintf("Metadata uses schema version %d and will be migrated to %s on the next change", from, metadata.Version))
+		result.Suggestions = append(result.Suggestions, "A backup of metadata.json is kept when the migration is written")
 	}
 
 	// Check Mozilla bundle info is present
@@ -272,19 +288,17 @@ func checkMetadata(store *certstore.Store) CheckResult {
 }
 
 // checkMozillaBundle verifies the Mozilla bundle exists and is valid.
-func checkMozillaBundle(store *certstore.Store) CheckResult {
+func checkMozillaBundle(snap *certstore.Snapshot) CheckResult {
 	result := CheckResult{
 		Name:   "Mozilla CA bundle",
 		Status: "pass",
 	}
 
-	mozillaPath := filepath.Join(store.BasePath(), "certs", "bundles", "mozilla-ca-bundle.pem")
-
 	// Check file exists
-	data, err := os.ReadFile(mozillaPath)
-	if err != nil {
+	data := snap.MozillaBundle
+	if snap.MozillaBundleErr != nil {
 		result.Status = "fail"
-		result.Issues = append(result.Issues, fmt.Sprintf("Cannot read Mozilla bundle: %v", err))
+		result.Issues = append(result.Issues, fmt.Sprintf("Cannot read Mozilla bundle: %v", snap.MozillaBundleErr))
 		result.Suggestions = append(result.Suggestions, "Run 'verifi bundle reset' to restore the embedded bundle")
 		return result
 	}
@@ -320,8 +334,7 @@ func checkMozillaBundle(store *certstore.Store) CheckResult {
 	}
 
 	// Verify hash matches metadata
-	metadata, err := store.GetMetadata()
-	if err == nil {
+	if metadata := snap.Metadata; metadata != nil {
 		actualSHA := fetcher.ComputeSHA256(data)
 		if actualSHA != metadata.MozillaBundle.SHA256 {
 			result.Status = "fail"
@@ -335,19 +348,17 @@ func checkMozillaBundle(store *certstore.Store) CheckResult {
 }
 
 // checkCombinedBundle verifies the combined bundle exists and is valid.
-func checkCombinedBundle(store *certstore.Store) CheckResult {
+func checkCombinedBundle(snap *certstore.Snapshot) CheckResult {
 	result := CheckResult{
 		Name:   "Combined certificate bundle",
 		Status: "pass",
 	}
 
-	combinedPath := store.CombinedBundlePath()
-
 	// Check file exists
-	data, err := os.ReadFile(combinedPath)
-	if err != nil {
+	data := snap.CombinedBundle
+	if snap.CombinedBundleErr != nil {
 		result.Status = "fail"
-		result.Issues = append(result.Issues, fmt.Sprintf("Cannot read combined bundle: %v", err))
+		result.Issues = append(result.Issues, fmt.Sprintf("Cannot read combined bundle: %v", snap.CombinedBundleErr))
 		result.Suggestions = append(result.Suggestions, "The combined bundle is missing or unreadable")
 		result.Suggestions = append(result.Suggestions, "Run 'verifi init --force' to recreate")
 		return result
@@ -377,8 +388,7 @@ func checkCombinedBundle(store *certstore.Store) CheckResult {
 	}
 
 	// Verify hash matches metadata
-	metadata, err := store.GetMetadata()
-	if err == nil {
+	if metadata := snap.Metadata; metadata != nil {
 		actualSHA := fetcher.ComputeSHA256(data)
 		if actualSHA != metadata.CombinedBundle.SHA256 {
 			result.Status = "fail"
@@ -392,18 +402,36 @@ func checkCombinedBundle(store *certstore.Store) CheckResult {
 }
 
 // checkUserCertificates verifies user certificates exist and are valid.
-func checkUserCertificates(ctx context.Context, store *certstore.Store) CheckResult {
+func checkUserCertificates(snap *certstore.Snapshot) CheckResult {
 	result := CheckResult{
 		Name:   "User certificates",
 		Status: "pass",
 	}
 
-	certs, err := store.ListCerts()
-	if err != nil {
+	if snap.MetadataErr != nil {
 		result.Status = "warn"
-		result.Issues = append(result.Issues, fmt.Sprintf("Cannot list certificates: %v", err))
+		result.Issues = append(result.Issues, fmt.Sprintf("Cannot list certificates: %v", snap.MetadataErr))
 		return result
 	}
+	certs := snap.UserCerts()
+
+	// Files in certs/user/ without a metadata entry are not in the combined bundle
+	tracked := make(map[string]bool)
+	for _, cert := range certs {
+		tracked[cert.Path] = true
+	}
+	untracked := 0
+	for _, rel := range snap.UserFileNames(".pem") {
+		if tracked[rel] {
+			continue
+		}
+		result.Status = "warn"
+		result.Issues = append(result.Issues, fmt.Sprintf("Untracked file (not in the combined bundle): %s", path.Base(rel)))
+		untracked++
+	}
+	if untracked > 0 {
+		result.Suggestions = append(result.Suggestions, "Add untracked files with 'verifi cert add' or remove them with 'verifi prune --orphans'")
+	}
 
 	if len(certs) == 0 {
 		// No user certs is not an error
@@ -411,26 +439,58 @@ func checkUserCertificates(ctx context.Context, store *certstore.Store) CheckRes
 		return result
 	}
 
-	now := time.Now()
+	now := snap.Taken
 	expiredCount := 0
 	missingCount := 0
+	lapsedCount := 0
+	expiringCount := 0
 
 	for _, cert := range certs {
-		certPath := filepath.Join(store.BasePath(), "certs", cert.Path)
+		if cert.Disabled {
+			// Not an error; shown so a temporary disable is not forgotten
+			result.Issues = append(result.Issues, fmt.Sprintf("Certificate disabled: %s", cert.Name))
+		}
+		if cert.TrustLapsed(now) {
+			if result.Status == "pass" {
+				result.Status = "warn"
+			}
+			result.Issues = append(result.Issues, fmt.Sprintf("Temporary trust lapsed: %s (since %s)", cert.Name, cert.TrustUntil.Format("2006-01-02 15:04")))
+			lapsedCount++
+		} else if !cert.TrustUntil.IsZero() {
+			result.Issues = append(result.Issues, fmt.Sprintf("Temporarily trusted: %s (%s)", cert.Name, formatRemaining(cert.TrustUntil.Sub(now))))
+		}
 
 		// Check if file exists
-		if _, err := os.Stat(certPath); os.IsNotExist(err) {
+		if !snap.HasUserFile(cert.Path) {
 			result.Status = "fail"
 			result.Issues = append(result.Issues, fmt.Sprintf("Certificate file missing: %s", cert.Name))
 			missingCount++
 			continue
 		}
 
+		// Replaced versions still in their overlap are part of the bundle too
+		for _, rev := range cert.Revisions {
+			if !rev.Active(now) {
+				continue
+			}
+			if !snap.HasUserFile(rev.Path) {
+				result.Status = "fail"
+				result.Issues = append(result.Issues, fmt.Sprintf("Retiring certificate file missing: %s (%s)", cert.Name, rev.Path))
+				missingCount++
+			}
+		}
+
 		// Check if expired
 		if now.After(cert.Expires) {
 			result.Status = "warn"
 			result.Issues = append(result.Issues, fmt.Sprintf("Certificate expired: %s (expired %s)", cert.Name, cert.Expires.Format("2006-01-02")))
 			expiredCount++
+		} else if cert.Expires.Before(now.Add(defaultExpiryWindow)) {
+			if result.Status == "pass" {
+				result.Status = "warn"
+			}
+			result.Issues = append(result.Issues, fmt.Sprintf("Certificate expires soon: %s (%s, on %s)", cert.Name, describeExpiry(now, cert.Expires), cert.Expires.Format("2006-01-02")))
+			expiringCount++
 		}
 	}
 
@@ -438,6 +498,14 @@ func checkUserCertificates(ctx context.Context, store *certstore.Store) CheckRes
 		result.Suggestions = append(result.Suggestions, fmt.Sprintf("Remove missing certificates or restore files (%d missing)", missingCount))
 	}
 
+	if lapsedCount > 0 {
+		result.Suggestions = append(result.Suggestions, fmt.Sprintf("Remove certificates whose temporary trust lapsed, or re-add them with a new --ttl (%d lapsed)", lapsedCount))
+	}
+
+	if expiringCount > 0 {
+		result.Suggestions = append(result.Suggestions, fmt.Sprintf("Rotate certificates that expire soon with 'verifi cert replace' (%d expiring within 30 days)", expiringCount))
+	}
+
 	if expiredCount > 0 {
 		result.Suggestions = append(result.Suggestions, fmt.Sprintf("Remove or update expired certificates (%d expired)", expiredCount))
 	}
@@ -445,6 +513,56 @@ func checkUserCertificates(ctx context.Context, store *certstore.Store) CheckRes
 	return result
 }
 
+// checkBundleContents scans user certificate files and the combined bundle for
+// PEM blocks that are not certificates. Private keys fail the check because the
+// combined bundle is world-readable; other blocks produce a warning.
+func checkBundleContents(store *certstore.Store, snap *certstore.Snapshot) CheckResult {
+	result := CheckResult{
+		Name:   "Bundle contents",
+		Status: "pass",
+	}
+
+	files := []string{}
+	contents := make(map[string][]byte)
+	for _, rel := range snap.UserFileNames(".pem") {
+		file := filepath.Join(store.BasePath(), "certs", filepath.FromSlash(rel))
+		files = append(files, file)
+		contents[file] = snap.UserFiles[rel]
+	}
+	// A missing combined bundle is caught by another check
+	if snap.CombinedBundleErr == nil {
+		files = append(files, store.CombinedBundlePath())
+		contents[store.CombinedBundlePath()] = snap.CombinedBundle
+	}
+
+	keyFound := false
+	for _, file := range files {
+		data := contents[file]
+
+		for _, blockType := range certstore.NonCertificateBlocks(data) {
+			if certstore.IsPrivateKeyBlock(blockType) {
+				result.Status = "fail"
+				keyFound = true
+				result.Issues = append(result.Issues, fmt.Sprintf("Private key (%s) found in %s", blockType, file))
+				continue
+			}
+			if result.Status == "pass" {
+				result.Status = "warn"
+			}
+			result.Issues = append(result.Issues, fmt.Sprintf("Unexpected %s block in %s", blockType, file))
+		}
+	}
+
+	if result.Status != "pass" {
+		result.Suggestions = append(result.Suggestions, "Remove and re-add the affected certificates with 'verifi cert remove' and 'verifi cert add'")
+	}
+	if keyFound {
+		result.Suggestions = append(result.Suggestions, "Treat the exposed private key as compromised and rotate it")
+	}
+
+	return result
+}
+
 // checkEnvFile verifies the env.sh file exists and contains correct variables.
 func checkEnvFile(store *certstore.Store) CheckResult {
 	result := CheckResult{
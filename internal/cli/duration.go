@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+)
+
+// untilLayouts are the time formats accepted by --until, in local time
+// unless the value carries a zone.
+var untilLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTrustUntil returns the trust expiry given by --ttl or --until, or the
+// zero time if neither is set. The expiry must be after now.
+func parseTrustUntil(ttl, until string, now time.Time) (time.Time, error) {
+	switch {
+	case ttl != "" && until != "":
+		return time.Time{}, fmt.Errorf("--ttl and --until cannot be used together")
+	case ttl != "":
+		d, err := certstore.ParseDuration(ttl)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --ttl: %w", err)
+		}
+		if d == 0 {
+			return time.Time{}, fmt.Errorf("invalid --ttl: must be greater than zero")
+		}
+		return now.Add(d), nil
+	case until != "":
+		value := strings.TrimSpace(until)
+		for _, layout := range untilLayouts {
+			t, err := time.ParseInLocation(layout, value, now.Location())
+			if err != nil {
+				continue
+			}
+			if !t.After(now) {
+				return time.Time{}, fmt.Errorf("invalid --until: %s is in the past", value)
+			}
+			return t, nil
+		}
+		return time.Time{}, fmt.Errorf("invalid --until %q (use e.g. \"2026-01-02 15:04\" or RFC 3339)", until)
+	}
+	return time.Time{}, nil
+}
+
+// formatRemaining renders the time left until a deadline, e.g. "7h 59m left"
+// or "3d 4h left". Durations that have passed are reported as "expired".
+func formatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "expired"
+	}
+
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh left", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm left", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm left", minutes)
+	default:
+		return "less than a minute left"
+	}
+}
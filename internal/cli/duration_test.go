@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrustUntil(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseTrustUntil("", "", now)
+	assert.NoError(t, err)
+	assert.True(t, got.IsZero(), "no flags should mean no expiry")
+
+	got, err = parseTrustUntil("8h", "", now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(8*time.Hour), got)
+
+	got, err = parseTrustUntil("", "2026-10-17 18:00", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 17, 18, 0, 0, 0, time.UTC), got)
+
+	got, err = parseTrustUntil("", "2026-10-17T09:30:00+02:00", now)
+	assert.NoError(t, err)
+	assert.True(t, got.Equal(time.Date(2026, 10, 17, 7, 30, 0, 0, time.UTC)))
+
+	for _, tt := range []struct{ ttl, until string }{
+		{ttl: "8h", until: "2026-10-17"},
+		{ttl: "0h"},
+		{ttl: "soon"},
+		{until: "2026-10-15"},
+		{until: "tomorrow"},
+	} {
+		_, err := parseTrustUntil(tt.ttl, tt.until, now)
+		assert.Error(t, err, "parseTrustUntil(%q, %q)", tt.ttl, tt.until)
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	assert.Equal(t, "expired", formatRemaining(-time.Minute))
+	assert.Equal(t, "less than a minute left", formatRemaining(30*time.Second))
+	assert.Equal(t, "45m left", formatRemaining(45*time.Minute))
+	assert.Equal(t, "7h 59m left", formatRemaining(8*time.Hour-time.Minute))
+	assert.Equal(t, "3d 4h left", formatRemaining(76*time.Hour))
+}
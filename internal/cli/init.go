@@ -36,7 +36,8 @@ The following structure will be created:
     logs/                # Optional logs
 
 Use --force to reinitialize an existing store (WARNING: this will reset your configuration).`,
-	RunE: runInit,
+	RunE:        runInit,
+	Annotations: mutates,
 }
 
 func init() {
@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+var (
+	pruneDryRun      bool
+	pruneForce       bool
+	pruneJSON        bool
+	pruneExpired     bool
+	pruneGrace       string
+	pruneLapsed      bool
+	pruneOrphans     bool
+	pruneSavePolicy  bool
+	pruneClearPolicy bool
+)
+
+// pruneCmd represents the prune command.
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired, lapsed and orphaned certificates and files",
+	Long: `Remove material that no longer belongs in the certificate store.
+
+What is pruned:
+  --expired   Certificates that have expired (with --grace, only those
+              expired for at least that long)
+  --lapsed    Certificates whose temporary trust (--ttl/--until) has ended
+  --orphans   Files in certs/user that no certificate refers to, certificates
+              whose file is missing, and stale *.tmp and *.lock files
+
+Without any of these flags, everything above is pruned. The plan is shown
+first and must be confirmed unless --force is given; --dry-run shows the
+plan only. The combined bundle is rebuilt when certificates are removed.
+
+Use --save-policy to keep the selection as the store's retention policy,
+which is then applied automatically after every command that changes the
+store. --clear-policy removes it.
+
+Examples:
+  verifi prune --dry-run
+  verifi prune --expired --grace 30d
+  verifi prune --orphans --force
+  verifi prune --expired --grace 90d --lapsed --save-policy
+  verifi prune --clear-policy`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be removed without removing anything")
+	pruneCmd.Flags().BoolVar(&pruneForce, "force", false, "Skip the confirmation prompt")
+	pruneCmd.Flags().BoolVar(&pruneJSON, "json", false, "Output in JSON format (requires --dry-run or --force)")
+	pruneCmd.Flags().BoolVar(&pruneExpired, "expired", false, "Prune expired certificates")
+	pruneCmd.Flags().StringVar(&pruneGrace, "grace", "", "Only prune certificates expired for at least this long (e.g. 30d); implies --expired")
+	pruneCmd.Flags().BoolVar(&pruneLapsed, "lapsed", false, "Prune certificates whose temporary trust has ended")
+	pruneCmd.Flags().BoolVar(&pruneOrphans, "orphans", false, "Prune untracked files, entries with missing files and stale temporary files")
+	pruneCmd.Flags().BoolVar(&pruneSavePolicy, "save-policy", false, "Save the selection as the retention policy instead of pruning now")
+	pruneCmd.Flags().BoolVar(&pruneClearPolicy, "clear-policy", false, "Remove the retention policy")
+	pruneCmd.MarkFlagsMutuallyExclusive("save-policy", "clear-policy")
+}
+
+// PruneOutput represents the result of the prune command.
+type PruneOutput struct {
+	DryRun    bool                   `json:"dry_run"`
+	Policy    certstore.PrunePolicy  `json:"policy"`
+	Items     []certstore.PruneItem  `json:"items"`
+	Retention *certstore.PrunePolicy `json:"retention,omitempty"`
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	policy, err := prunePolicyFromFlags()
+	if err != nil {
+		Error("%v", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	if pruneJSON && !pruneDryRun && !pruneForce {
+		Error("--json requires --dry-run or --force")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if pruneSavePolicy || pruneClearPolicy {
+		var retention *certstore.PrunePolicy
+		if pruneSavePolicy {
+			retention = &policy
+		}
+		if err := store.SetRetentionPolicy(ctx, retention); err != nil {
+			exitOnPruneError(err, "save retention policy")
+		}
+		if retention == nil {
+			Success("Retention policy removed")
+			return nil
+		}
+		Success("Retention policy saved: %s", describePrunePolicy(policy))
+		Info("It is applied after every command that changes the store; run 'verifi prune' to apply it now")
+		return nil
+	}
+
+	items, err := store.PlanPrune(policy)
+	if err != nil {
+		exitOnPruneError(err, "plan prune")
+	}
+
+	output := PruneOutput{DryRun: pruneDryRun, Policy: policy, Items: items}
+	if metadata, err := store.GetMetadata(); err == nil {
+		output.Retention = metadata.Retention
+	}
+
+	if !pruneJSON {
+		printPrunePlan(output)
+	}
+
+	if len(items) > 0 && !pruneDryRun {
+		if !pruneForce && !ConfirmPrompt(fmt.Sprintf("Remove these %d item(s)?", len(items))) {
+			Info("Aborted. Nothing was removed.")
+			return nil
+		}
+
+		output.Items, err = store.Prune(ctx, policy)
+		if err != nil {
+			exitOnPruneError(err, "prune store")
+		}
+		if !pruneJSON {
+			EmptyLine()
+			Success("Removed %d item(s)", len(output.Items))
+		}
+	}
+
+	if pruneJSON {
+		if output.Items == nil {
+			output.Items = []certstore.PruneItem{}
+		}
+		if err := JSON(output); err != nil {
+			Error("Failed to encode JSON: %v", err)
+			os.Exit(verifierrors.ExitGeneralError)
+		}
+	}
+
+	return nil
+}
+
+// prunePolicyFromFlags returns the selection made with the prune flags.
+// Without any selection flag, everything is selected.
+func prunePolicyFromFlags() (certstore.PrunePolicy, error) {
+	policy := certstore.PrunePolicy{
+		Expired: pruneExpired,
+		Lapsed:  pruneLapsed,
+		Orphans: pruneOrphans,
+	}
+	if pruneGrace != "" {
+		grace, err := certstore.ParseDuration(pruneGrace)
+		if err != nil {
+			return policy, fmt.Errorf("invalid --grace: %w", err)
+		}
+		policy.Expired = true
+		policy.Grace = grace
+	}
+	if policy.IsZero() && !pruneClearPolicy {
+		policy = certstore.PrunePolicy{Expired: true, Lapsed: true, Orphans: true}
+	}
+	return policy, nil
+}
+
+// describePrunePolicy renders a policy as e.g. "expired (after 30d), lapsed".
+func describePrunePolicy(policy certstore.PrunePolicy) string {
+	var parts []string
+	if policy.Expired {
+		if policy.Grace > 0 {
+			parts = append(parts, fmt.Sprintf("expired (after %s)", certstore.FormatDuration(policy.Grace)))
+		} else {
+			parts = append(parts, "expired")
+		}
+	}
+	if policy.Lapsed {
+		parts = append(parts, "lapsed")
+	}
+	if policy.Orphans {
+		parts = append(parts, "orphans")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printPrunePlan prints what prune is about to remove.
+func printPrunePlan(output PruneOutput) {
+	Field("Pruning", describePrunePolicy(output.Policy))
+	if output.Retention != nil {
+		Field("Retention policy", describePrunePolicy(*output.Retention))
+	}
+	EmptyLine()
+
+	if len(output.Items) == 0 {
+		Success("Nothing to prune")
+		return
+	}
+
+	if output.DryRun {
+		fmt.Printf("Would remove %d item(s)\n\n", len(output.Items))
+	} else {
+		fmt.Printf("Prune plan (%d items)\n\n", len(output.Items))
+	}
+
+	table := NewTable("KIND", "ITEM", "DETAIL")
+	for _, item := range output.Items {
+		table.AddRow(string(item.Kind), pruneItemLabel(item), pruneItemDetail(item))
+	}
+	table.Print()
+}
+
+// pruneItemLabel names an item by certificate name, or by path for files.
+func pruneItemLabel(item certstore.PruneItem) string {
+	if item.Name != "" {
+		return item.Name
+	}
+	return item.Path
+}
+
+// pruneItemDetail explains why an item is pruned.
+func pruneItemDetail(item certstore.PruneItem) string {
+	switch item.Kind {
+	case certstore.PruneExpired:
+		return "expired " + item.Since.Format("2006-01-02")
+	case certstore.PruneLapsed:
+		return "trust ended " + item.Since.Format("2006-01-02 15:04")
+	case certstore.PruneMissing:
+		return "file missing: " + item.Path
+	case certstore.PruneUntracked:
+		return "not in metadata"
+	case certstore.PruneTemp:
+		return "stale temporary file"
+	}
+	return ""
+}
+
+// exitOnPruneError reports a prune error and exits.
+func exitOnPruneError(err error, action string) {
+	if errors.Is(err, verifierrors.ErrMetadataTooNew) {
+		Error("%v", err)
+		fmt.Fprintf(os.Stderr, "Update verifi to the latest version to modify this store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	Error("Failed to %s: %v", action, err)
+	os.Exit(verifierrors.ExitGeneralError)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+)
+
+func TestPrunePolicyFromFlags(t *testing.T) {
+	reset := func() {
+		pruneExpired, pruneLapsed, pruneOrphans, pruneClearPolicy = false, false, false, false
+		pruneGrace = ""
+	}
+	t.Cleanup(reset)
+
+	t.Run("defaults to everything", func(t *testing.T) {
+		reset()
+		policy, err := prunePolicyFromFlags()
+		require.NoError(t, err)
+		assert.Equal(t, certstore.PrunePolicy{Expired: true, Lapsed: true, Orphans: true}, policy)
+	})
+
+	t.Run("grace implies expired", func(t *testing.T) {
+		reset()
+		pruneGrace = "30d"
+		policy, err := prunePolicyFromFlags()
+		require.NoError(t, err)
+		assert.Equal(t, certstore.PrunePolicy{Expired: true, Grace: 30 * 24 * time.Hour}, policy)
+	})
+
+	t.Run("invalid grace", func(t *testing.T) {
+		reset()
+		pruneGrace = "soon"
+		_, err := prunePolicyFromFlags()
+		assert.Error(t, err)
+	})
+}
+
+func TestDescribePrunePolicy(t *testing.T) {
+	assert.Equal(t, "none", describePrunePolicy(certstore.PrunePolicy{}))
+	assert.Equal(t, "expired (after 30d), lapsed", describePrunePolicy(certstore.PrunePolicy{Expired: true, Grace: 30 * 24 * time.Hour, Lapsed: true}))
+	assert.Equal(t, "expired (after 12h0m0s), orphans", describePrunePolicy(certstore.PrunePolicy{Expired: true, Grace: 12 * time.Hour, Orphans: true}))
+}
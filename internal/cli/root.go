@@ -2,10 +2,16 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
 )
 
 // Version information (will be set by build flags in production).
@@ -15,6 +21,13 @@ var (
 	BuildDate = "unknown"
 )
 
+// annotationMutates marks commands that change the store. The retention
+// policy is applied after they succeed.
+const annotationMutates = "verifi/mutates"
+
+// mutates is the Annotations value of commands that change the store.
+var mutates = map[string]string{annotationMutates: "true"}
+
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
 	Use:   "verifi",
@@ -26,6 +39,14 @@ development environments through environment variables, eliminating the
 need to configure certificates separately for npm, pip, git, curl, etc.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		refreshStore()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if cmd.Annotations[annotationMutates] == "true" {
+			applyRetention()
+		}
+	},
 }
 
 // versionCmd represents the version command.
@@ -45,8 +66,79 @@ func init() {
 
 // Execute runs the root command and handles errors.
 func Execute() {
+	certstore.WriterVersion = Version
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// refreshStore prepares the store before any command runs. An operation
+// interrupted by a crash is first completed or rolled back from the journal,
+// then time-based changes are applied: replaced certificates whose rotation
+// overlap has ended are retired and certificates whose temporary trust has
+// lapsed are dropped from the combined bundle. Notices go to stderr so JSON
+// output is not affected, and failures never stop the command itself.
+func refreshStore() {
+	store, err := certstore.NewStore("")
+	if err != nil || !store.IsInitialized() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	recovered, err := store.Recover(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to recover an interrupted operation: %v\n", err)
+		return
+	}
+	if recovered != nil {
+		if recovered.RolledBack {
+			fmt.Fprintf(os.Stderr, "Rolled back an interrupted '%s' from %s\n", recovered.Op, recovered.Started.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Fprintf(os.Stderr, "Completed an interrupted '%s' from %s\n", recovered.Op, recovered.Started.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	result, err := store.Refresh(ctx)
+	if err != nil {
+		if !errors.Is(err, verifierrors.ErrMetadataTooNew) {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to refresh the certificate store: %v\n", err)
+		}
+		return
+	}
+
+	for _, name := range result.LapsedTrust {
+		fmt.Fprintf(os.Stderr, "Temporary trust for '%s' has expired; it was removed from the combined bundle\n", name)
+	}
+	if result.RetiredRevisions > 0 {
+		fmt.Fprintf(os.Stderr, "Retired %d replaced certificate(s) whose overlap has ended; combined bundle rebuilt\n", result.RetiredRevisions)
+	}
+}
+
+// applyRetention prunes the store according to its retention policy after a
+// command changed it. Like refreshStore, it reports on stderr and never fails
+// the command.
+func applyRetention() {
+	store, err := certstore.NewStore("")
+	if err != nil || !store.IsInitialized() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	items, err := store.ApplyRetention(ctx)
+	if err != nil {
+		if !errors.Is(err, verifierrors.ErrMetadataTooNew) {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to apply the retention policy: %v\n", err)
+		}
+		return
+	}
+
+	for _, item := range items {
+		fmt.Fprintf(os.Stderr, "Retention policy removed %s (%s)\n", pruneItemLabel(item), pruneItemDetail(item))
+	}
+}
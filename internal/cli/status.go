@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -51,18 +53,20 @@ type StatusOutput struct {
 
 // UserCertsStatus represents user certificate information.
 type UserCertsStatus struct {
-	Count int                      `json:"count"`
-	Certs []certstore.UserCertInfo `json:"certs,omitempty"`
+	Count    int                      `json:"count"`
+	Certs    []certstore.UserCertInfo `json:"certs,omitempty"`
+	Expiring []string                 `json:"expiring,omitempty"` // names expiring within 30 days (or expired)
 }
 
 // CombinedBundleStatus represents combined bundle information.
 type CombinedBundleStatus struct {
-	Path      string    `json:"path"`
-	CertCount int       `json:"cert_count"`
-	Generated time.Time `json:"generated"`
-	SHA256    string    `json:"sha256"`
-	SizeBytes int64     `json:"size_bytes"`
-	Sources   []string  `json:"sources"`
+	Path       string                    `json:"path"`
+	CertCount  int                       `json:"cert_count"`
+	Generated  time.Time                 `json:"generated"`
+	SHA256     string                    `json:"sha256"`
+	SizeBytes  int64                     `json:"size_bytes"`
+	Sources    []string                  `json:"sources"`
+	Duplicates []certstore.DuplicateCert `json:"duplicates,omitempty"`
 }
 
 // MozillaBundleStatus represents Mozilla bundle information.
@@ -114,27 +118,36 @@ func gatherStatus(store *certstore.Store) StatusOutput {
 		return status
 	}
 
-	// Get user certificates
-	if userCerts, err := store.ListCerts(); err == nil {
-		status.UserCerts.Count = len(userCerts)
-		status.UserCerts.Certs = userCerts
-	}
+	// Read metadata and bundles consistently, even during an update
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	snap, err := store.Snapshot(ctx)
+	if err == nil && snap.Metadata != nil {
+		metadata := snap.Metadata
+
+		// User certificates
+		status.UserCerts.Count = len(metadata.UserCerts)
+		status.UserCerts.Certs = metadata.UserCerts
+
+		deadline := snap.Taken.Add(defaultExpiryWindow)
+		for _, cert := range metadata.UserCerts {
+			if cert.Expires.Before(deadline) {
+				status.UserCerts.Expiring = append(status.UserCerts.Expiring, cert.Name)
+			}
+		}
 
-	// Get metadata for bundle info
-	if metadata, err := store.GetMetadata(); err == nil {
 		// Combined bundle
-		bundlePath := store.CombinedBundlePath()
 		status.CombinedBundle = CombinedBundleStatus{
-			Path:      bundlePath,
-			CertCount: metadata.CombinedBundle.CertCount,
-			Generated: metadata.CombinedBundle.Generated,
-			SHA256:    metadata.CombinedBundle.SHA256,
-			Sources:   metadata.CombinedBundle.Sources,
+			Path:       store.CombinedBundlePath(),
+			CertCount:  metadata.CombinedBundle.CertCount,
+			Generated:  metadata.CombinedBundle.Generated,
+			SHA256:     metadata.CombinedBundle.SHA256,
+			Sources:    metadata.CombinedBundle.Sources,
+			Duplicates: metadata.CombinedBundle.Duplicates,
 		}
-
-		// Get file size
-		if info, err := os.Stat(bundlePath); err == nil {
-			status.CombinedBundle.SizeBytes = info.Size()
+		if snap.CombinedBundleErr == nil {
+			status.CombinedBundle.SizeBytes = int64(len(snap.CombinedBundle))
 		}
 
 		// Mozilla bundle
@@ -147,7 +160,7 @@ func gatherStatus(store *certstore.Store) StatusOutput {
 
 	// Check env.sh
 	envPath := shell.EnvFilePath(store.BasePath())
-	_, err := os.Stat(envPath)
+	_, err = os.Stat(envPath)
 	status.EnvFile = EnvFileStatus{
 		Exists: err == nil,
 		Path:   envPath,
@@ -176,9 +189,22 @@ func printStatusHuman(status StatusOutput) {
 	if status.UserCerts.Count > 0 {
 		EmptyLine()
 		for _, cert := range status.UserCerts.Certs {
-			fmt.Printf("  • %s\n", cert.Name)
+			switch {
+			case cert.Disabled:
+				fmt.Printf("  • %s (disabled)\n", cert.Name)
+			case cert.TrustLapsed(time.Now()):
+				fmt.Printf("  • %s (trust lapsed)\n", cert.Name)
+			case !cert.TrustUntil.IsZero():
+				fmt.Printf("  • %s (temporary, %s)\n", cert.Name, formatRemaining(time.Until(cert.TrustUntil)))
+			default:
+				fmt.Printf("  • %s\n", cert.Name)
+			}
 			FieldIndented("Subject", cert.Subject, 4)
-			FieldIndented("Expires", cert.Expires.Format("2006-01-02 15:04:05 MST"), 4)
+			expires := cert.Expires.Format("2006-01-02 15:04:05 MST")
+			if cert.Expires.Before(time.Now().Add(defaultExpiryWindow)) {
+				expires += " " + Color("("+describeExpiry(time.Now(), cert.Expires)+")", colorYellow)
+			}
+			FieldIndented("Expires", expires, 4)
 			FieldIndented("Added", cert.Added.Format("2006-01-02 15:04:05 MST"), 4)
 		}
 	}
@@ -197,6 +223,12 @@ func printStatusHuman(status StatusOutput) {
 	if !status.CombinedBundle.Generated.IsZero() {
 		Field("Generated", status.CombinedBundle.Generated.Format("2006-01-02 15:04:05 MST"))
 	}
+	if len(status.CombinedBundle.Duplicates) > 0 {
+		Field("Duplicates", fmt.Sprintf("%d (merged)", len(status.CombinedBundle.Duplicates)))
+		for _, dup := range status.CombinedBundle.Duplicates {
+			fmt.Printf("  - %s [%s]\n", dup.Subject, strings.Join(dup.Sources, ", "))
+		}
+	}
 	EmptyLine()
 
 	// Mozilla bundle
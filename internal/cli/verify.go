@@ -0,0 +1,341 @@
+package cli
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+	"github.com/princespaghetti/verifi/internal/fetcher"
+)
+
+var (
+	verifyJSON    bool
+	verifySNI     string
+	verifyChain   string
+	verifyDNSName string
+	verifyRoots   string
+)
+
+// verifyCmd represents the verify command.
+var verifyCmd = &cobra.Command{
+	Use:   "verify [url]",
+	Short: "Verify a live TLS connection or a chain file against the store",
+	Long: `Verify a certificate chain against the combined bundle.
+
+With a URL, verifi connects to the server and verifies the chain it
+presents. With --chain, a local chain file (leaf first, followed by any
+intermediates) is verified offline instead; use --dns-name to also check
+the leaf against a host name.
+
+This checks exactly what tools configured through env.sh will trust. Use
+--roots to verify against only the Mozilla bundle or only your user
+certificates. On success, every chain path that was built is shown, along
+with the trust anchor and whether it came from the Mozilla bundle or a
+named user certificate. On failure, the reason is reported: unknown
+authority, expired, name mismatch or incompatible key usage (EKU).
+
+Exit codes:
+  0 - Chain verified
+  2 - Invalid arguments
+  3 - Certificate verification failed
+  4 - Network error (connection or handshake failed)
+
+Examples:
+  verifi verify https://internal.corp.com
+  verifi verify registry.npmjs.org:443
+  verifi verify 10.0.0.5:8443 --sni git.corp.com
+  verifi verify https://pypi.org --json
+  verifi verify --chain server.pem --dns-name internal.corp.com
+  verifi verify --chain server.pem --roots user`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runVerify,
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().BoolVar(&verifyJSON, "json", false, "Output in JSON format")
+	verifyCmd.Flags().StringVar(&verifySNI, "sni", "", "Override the TLS server name (SNI) and verified host name")
+	verifyCmd.Flags().StringVar(&verifyChain, "chain", "", "Verify a local chain file instead of connecting to a server")
+	verifyCmd.Flags().StringVar(&verifyDNSName, "dns-name", "", "Host name the leaf must be valid for (with --chain)")
+	verifyCmd.Flags().StringVar(&verifyRoots, "roots", certstore.RootsAll, "Trust anchors to verify against: all, mozilla or user")
+}
+
+// VerifyOutput represents the result of the verify command.
+type VerifyOutput struct {
+	Target        string             `json:"target,omitempty"`
+	ChainFile     string             `json:"chain_file,omitempty"`
+	Address       string             `json:"address,omitempty"`
+	ServerName    string             `json:"server_name,omitempty"`
+	DNSName       string             `json:"dns_name,omitempty"`
+	Roots         string             `json:"roots"`
+	Verified      bool               `json:"verified"`
+	ErrorKind     string             `json:"error_kind,omitempty"` // "config", "input", "network", "verification", "store"
+	FailureReason string             `json:"failure_reason,omitempty"`
+	Error         string             `json:"error,omitempty"`
+	Chain         []VerifyCertInfo   `json:"chain,omitempty"`
+	Paths         [][]VerifyCertInfo `json:"paths,omitempty"`
+	Anchor        *VerifyCertInfo    `json:"anchor,omitempty"`
+	AnchorSource  string             `json:"anchor_source,omitempty"`
+}
+
+// VerifyCertInfo describes a certificate in verify output.
+type VerifyCertInfo struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Fingerprint string    `json:"fingerprint"`
+	Expires     time.Time `json:"expires"`
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	// Create store
+	store, err := certstore.NewStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to create store: %v\n", err)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	// Check if initialized
+	if !store.IsInitialized() {
+		fmt.Fprintf(os.Stderr, "Error: Certificate store not initialized\n")
+		fmt.Fprintf(os.Stderr, "Run 'verifi init' first to initialize the store\n")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	if (len(args) == 0) == (verifyChain == "") {
+		Error("Specify either a URL or --chain <file>")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	if verifyChain == "" && verifyDNSName != "" {
+		Error("--dns-name is only used with --chain; use --sni to change the verified host of a URL")
+		os.Exit(verifierrors.ExitConfigError)
+	}
+	switch verifyRoots {
+	case certstore.RootsAll, certstore.RootsMozilla, certstore.RootsUser:
+	default:
+		Error("Invalid --roots %q: expected %s, %s or %s", verifyRoots, certstore.RootsAll, certstore.RootsMozilla, certstore.RootsUser)
+		os.Exit(verifierrors.ExitConfigError)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var output VerifyOutput
+	var exitCode int
+	if verifyChain != "" {
+		output, exitCode = verifyChainFile(ctx, store, verifyChain, verifyDNSName, verifyRoots)
+	} else {
+		output, exitCode = verifyTarget(ctx, store, args[0], verifySNI, verifyRoots)
+	}
+
+	if verifyJSON {
+		if err := JSON(output); err != nil {
+			Error("Failed to encode JSON: %v", err)
+			os.Exit(verifierrors.ExitGeneralError)
+		}
+	} else {
+		printVerifyOutput(output)
+	}
+
+	if exitCode != verifierrors.ExitSuccess {
+		os.Exit(exitCode)
+	}
+
+	return nil
+}
+
+// verifyTarget connects to target and verifies the presented chain against
+// the selected roots. It returns the output and the exit code.
+func verifyTarget(ctx context.Context, store *certstore.Store, target, sni, roots string) (VerifyOutput, int) {
+	output := VerifyOutput{Target: target, Roots: rootsOrDefault(roots)}
+
+	addr, host, err := fetcher.ParseTarget(target)
+	if err != nil {
+		output.ErrorKind = "config"
+		output.Error = err.Error()
+		return output, verifierrors.ExitConfigError
+	}
+
+	serverName := host
+	if sni != "" {
+		serverName = sni
+	}
+	output.Address = addr
+	output.ServerName = serverName
+
+	chain, err := fetcher.FetchServerChain(ctx, addr, serverName)
+	if err != nil {
+		output.ErrorKind = "network"
+		output.Error = err.Error()
+		return output, verifierrors.ExitNetworkError
+	}
+
+	return verifyCerts(ctx, store, chain, serverName, output)
+}
+
+// verifyChainFile verifies a local chain file (leaf first) against the
+// selected roots without any network access.
+func verifyChainFile(ctx context.Context, store *certstore.Store, path, dnsName, roots string) (VerifyOutput, int) {
+	output := VerifyOutput{ChainFile: path, DNSName: dnsName, Roots: rootsOrDefault(roots)}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- user-specified chain file path is intentional
+	if err != nil {
+		output.ErrorKind = "input"
+		output.Error = err.Error()
+		return output, verifierrors.ExitConfigError
+	}
+
+	pemData, _, err := certstore.ConvertToPEM(data, "")
+	if err != nil {
+		output.ErrorKind = "input"
+		output.Error = err.Error()
+		return output, verifierrors.ExitCertError
+	}
+
+	// Expired certificates are parsed so that verification can report them
+	chain, _, err := certstore.ValidateCertChain(pemData, true)
+	if err != nil {
+		output.ErrorKind = "input"
+		output.Error = err.Error()
+		return output, verifierrors.ExitCertError
+	}
+
+	return verifyCerts(ctx, store, chain, dnsName, output)
+}
+
+// verifyCerts verifies chain against the store and fills in output.
+func verifyCerts(ctx context.Context, store *certstore.Store, chain []*x509.Certificate, dnsName string, output VerifyOutput) (VerifyOutput, int) {
+	for _, cert := range chain {
+		output.Chain = append(output.Chain, newVerifyCertInfo(cert))
+	}
+
+	result, err := store.VerifyChain(ctx, chain, certstore.VerifyOptions{
+		DNSName: dnsName,
+		Roots:   output.Roots,
+	})
+	if err != nil {
+		output.Error = err.Error()
+		if errors.Is(err, verifierrors.ErrVerificationFailed) {
+			output.ErrorKind = "verification"
+			output.FailureReason = certstore.VerifyFailureReason(err)
+			return output, verifierrors.ExitCertError
+		}
+		output.ErrorKind = "store"
+		return output, verifierrors.ExitGeneralError
+	}
+
+	for _, path := range result.Chains {
+		var infos []VerifyCertInfo
+		for _, cert := range path {
+			infos = append(infos, newVerifyCertInfo(cert))
+		}
+		output.Paths = append(output.Paths, infos)
+	}
+
+	anchor := newVerifyCertInfo(result.Anchor)
+	output.Verified = true
+	output.Anchor = &anchor
+	output.AnchorSource = result.AnchorSource
+
+	return output, verifierrors.ExitSuccess
+}
+
+// rootsOrDefault returns roots, or the combined bundle if roots is empty.
+func rootsOrDefault(roots string) string {
+	if roots == "" {
+		return certstore.RootsAll
+	}
+	return roots
+}
+
+// newVerifyCertInfo converts a certificate to its output form.
+func newVerifyCertInfo(cert *x509.Certificate) VerifyCertInfo {
+	return VerifyCertInfo{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Fingerprint: certstore.Fingerprint(cert),
+		Expires:     cert.NotAfter,
+	}
+}
+
+// printVerifyOutput prints the verify result in a human-readable format.
+func printVerifyOutput(output VerifyOutput) {
+	if output.Address != "" {
+		Info("Connecting to %s (server name %s)...", output.Address, output.ServerName)
+		EmptyLine()
+	}
+
+	if len(output.Chain) > 0 {
+		label := "Presented chain"
+		if output.ChainFile != "" {
+			label = fmt.Sprintf("Certificates in %s", output.ChainFile)
+		}
+		fmt.Printf("%s (%d)\n", label, len(output.Chain))
+		for i, cert := range output.Chain {
+			fmt.Printf("  %d. %s\n", i+1, cert.Subject)
+			FieldIndented("Issuer", cert.Issuer, 5)
+		}
+		EmptyLine()
+	}
+
+	if !output.Verified {
+		switch output.ErrorKind {
+		case "network":
+			Error("Connection failed: %s", output.Error)
+		case "input":
+			Error("Failed to read chain: %s", output.Error)
+		case "verification":
+			Error("Verification failed (%s)", describeFailureReason(output.FailureReason))
+			FieldIndented("Details", output.Error, 2)
+		default:
+			Error("%s", output.Error)
+		}
+		return
+	}
+
+	Success("Certificate chain verified against %s roots", output.Roots)
+	for i, path := range output.Paths {
+		fmt.Printf("  Path %d:\n", i+1)
+		for j, cert := range path {
+			fmt.Printf("    %s%s\n", strings.Repeat("  ", j), cert.Subject)
+		}
+	}
+	EmptyLine()
+	FieldIndented("Trust anchor", output.Anchor.Subject, 2)
+	FieldIndented("Anchor source", describeAnchorSource(output.AnchorSource), 2)
+	FieldIndented("Fingerprint", output.Anchor.Fingerprint, 2)
+}
+
+// describeFailureReason turns a verification failure reason into a human-readable label.
+func describeFailureReason(reason string) string {
+	switch reason {
+	case certstore.FailureUnknownAuthority:
+		return "certificate signed by unknown authority"
+	case certstore.FailureExpired:
+		return "certificate expired or not yet valid"
+	case certstore.FailureNameMismatch:
+		return "host name mismatch"
+	case certstore.FailureIncompatibleUsage:
+		return "incompatible key usage (EKU)"
+	default:
+		return "other error"
+	}
+}
+
+// describeAnchorSource turns an anchor source into a human-readable label.
+func describeAnchorSource(source string) string {
+	if source == certstore.AnchorSourceMozilla {
+		return "Mozilla CA bundle"
+	}
+	if name, ok := strings.CutPrefix(source, "user:"); ok {
+		return fmt.Sprintf("user certificate '%s'", name)
+	}
+	return source
+}
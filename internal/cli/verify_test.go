@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/princespaghetti/verifi/internal/certstore"
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+func TestVerifyCmd_Flags(t *testing.T) {
+	for _, name := range []string{"json", "sni", "chain", "dns-name", "roots"} {
+		assert.NotNil(t, verifyCmd.Flags().Lookup(name), "--%s flag not found", name)
+	}
+}
+
+func TestVerifyTarget(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	store, _ := initTestStore(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("untrusted server", func(t *testing.T) {
+		output, code := verifyTarget(ctx, store, server.URL, "", "")
+		assert.Equal(t, verifierrors.ExitCertError, code)
+		assert.False(t, output.Verified)
+		assert.Equal(t, "verification", output.ErrorKind)
+		assert.Equal(t, certstore.FailureUnknownAuthority, output.FailureReason)
+		assert.NotEmpty(t, output.Chain)
+	})
+
+	t.Run("trusted via user cert", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "server.pem")
+		require.NoError(t, os.WriteFile(certPath, certstore.EncodeCertsPEM([]*x509.Certificate{server.Certificate()}), 0644))
+		require.NoError(t, store.AddCert(ctx, certPath, "test-server", false))
+
+		output, code := verifyTarget(ctx, store, server.URL, "", "")
+		assert.Equal(t, verifierrors.ExitSuccess, code)
+		assert.True(t, output.Verified)
+		require.NotNil(t, output.Anchor)
+		assert.Equal(t, certstore.Fingerprint(server.Certificate()), output.Anchor.Fingerprint)
+		assert.Equal(t, "user:test-server", output.AnchorSource)
+		assert.Len(t, output.Paths, 1)
+	})
+
+	t.Run("SNI mismatch", func(t *testing.T) {
+		output, code := verifyTarget(ctx, store, server.URL, "wrong.invalid", "")
+		assert.Equal(t, verifierrors.ExitCertError, code)
+		assert.Equal(t, "wrong.invalid", output.ServerName)
+		assert.Equal(t, certstore.FailureNameMismatch, output.FailureReason)
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		closed := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		closedURL := closed.URL
+		closed.Close()
+
+		output, code := verifyTarget(ctx, store, closedURL, "", "")
+		assert.Equal(t, verifierrors.ExitNetworkError, code)
+		assert.Equal(t, "network", output.ErrorKind)
+	})
+}
+
+func TestVerifyChainFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close() // Only the certificate is needed; verification is offline
+
+	store, _ := initTestStore(t)
+	ctx := context.Background()
+
+	chainPath := filepath.Join(t.TempDir(), "server.pem")
+	require.NoError(t, os.WriteFile(chainPath, certstore.EncodeCertsPEM([]*x509.Certificate{server.Certificate()}), 0644))
+
+	t.Run("unknown authority", func(t *testing.T) {
+		output, code := verifyChainFile(ctx, store, chainPath, "", "")
+		assert.Equal(t, verifierrors.ExitCertError, code)
+		assert.Equal(t, certstore.FailureUnknownAuthority, output.FailureReason)
+		assert.Len(t, output.Chain, 1)
+	})
+
+	require.NoError(t, store.AddCert(ctx, chainPath, "test-server", false))
+
+	t.Run("verified", func(t *testing.T) {
+		output, code := verifyChainFile(ctx, store, chainPath, "example.com", certstore.RootsUser)
+		assert.Equal(t, verifierrors.ExitSuccess, code)
+		assert.True(t, output.Verified)
+		assert.Equal(t, certstore.RootsUser, output.Roots)
+		assert.NotEmpty(t, output.Paths)
+	})
+
+	t.Run("name mismatch", func(t *testing.T) {
+		output, code := verifyChainFile(ctx, store, chainPath, "other.invalid", "")
+		assert.Equal(t, verifierrors.ExitCertError, code)
+		assert.Equal(t, certstore.FailureNameMismatch, output.FailureReason)
+	})
+
+	t.Run("mozilla roots only", func(t *testing.T) {
+		output, code := verifyChainFile(ctx, store, chainPath, "", certstore.RootsMozilla)
+		assert.Equal(t, verifierrors.ExitCertError, code)
+		assert.Equal(t, certstore.FailureUnknownAuthority, output.FailureReason)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		output, code := verifyChainFile(ctx, store, filepath.Join(t.TempDir(), "missing.pem"), "", "")
+		assert.Equal(t, verifierrors.ExitConfigError, code)
+		assert.Equal(t, "input", output.ErrorKind)
+	})
+}
+
+func TestDescribeAnchorSource(t *testing.T) {
+	assert.Equal(t, "Mozilla CA bundle", describeAnchorSource(certstore.AnchorSourceMozilla))
+	assert.Equal(t, "user certificate 'corp'", describeAnchorSource("user:corp"))
+	assert.Equal(t, "unknown", describeAnchorSource(certstore.AnchorSourceUnknown))
+}
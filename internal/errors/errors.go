@@ -27,18 +27,29 @@ func (e *VerifiError) Unwrap() error {
 
 // Predefined errors for common scenarios.
 var (
-	ErrCertExpired      = fmt.Errorf("certificate has expired")
-	ErrInvalidPEM       = fmt.Errorf("invalid PEM format")
-	ErrCertNotFound     = fmt.Errorf("certificate not found")
-	ErrStoreNotInit     = fmt.Errorf("certificate store not initialized")
-	ErrStoreAlreadyInit = fmt.Errorf("certificate store already initialized")
+	ErrCertExpired        = fmt.Errorf("certificate has expired")
+	ErrInvalidPEM         = fmt.Errorf("invalid PEM format")
+	ErrCertNotFound       = fmt.Errorf("certificate not found")
+	ErrCertExists         = fmt.Errorf("certificate already exists")
+	ErrStoreNotInit       = fmt.Errorf("certificate store not initialized")
+	ErrStoreAlreadyInit   = fmt.Errorf("certificate store already initialized")
+	ErrNoCACerts          = fmt.Errorf("no CA certificates found")
+	ErrIncorrectPassword  = fmt.Errorf("incorrect or missing password")
+	ErrVerificationFailed = fmt.Errorf("certificate verification failed")
+	ErrPrivateKey         = fmt.Errorf("input contains a private key")
+	ErrLeafCert           = fmt.Errorf("certificate is a leaf (server) certificate, not a CA")
+	ErrMetadataTooNew     = fmt.Errorf("metadata was written by a newer version of verifi")
+	ErrChecksumMismatch   = fmt.Errorf("checksum mismatch")
+	ErrChecksumMissing    = fmt.Errorf("checksum not published")
+	ErrSourcesDisagree    = fmt.Errorf("bundle sources disagree")
 )
 
 // Exit codes - use these constants in CLI commands instead of hardcoding values.
 const (
-	ExitSuccess      = 0 // Success
-	ExitGeneralError = 1 // General error (file I/O, permissions)
-	ExitConfigError  = 2 // Configuration error (invalid config, missing values)
-	ExitCertError    = 3 // Certificate error (invalid cert, expired, verification failed)
-	ExitNetworkError = 4 // Network error (failed to fetch Mozilla bundle)
+	ExitSuccess       = 0 // Success
+	ExitGeneralError  = 1 // General error (file I/O, permissions)
+	ExitConfigError   = 2 // Configuration error (invalid config, missing values)
+	ExitCertError     = 3 // Certificate error (invalid cert, expired, verification failed)
+	ExitNetworkError  = 4 // Network error (failed to fetch Mozilla bundle)
+	ExitExpiryWarning = 5 // Certificates expire within the checked window (check expiry)
 )
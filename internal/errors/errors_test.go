@@ -130,11 +130,12 @@ func TestErrorWrapping(t *testing.T) {
 func TestExitCodes(t *testing.T) {
 	// Verify exit codes are distinct and in expected range
 	codes := map[string]int{
-		"ExitSuccess":      ExitSuccess,
-		"ExitGeneralError": ExitGeneralError,
-		"ExitConfigError":  ExitConfigError,
-		"ExitCertError":    ExitCertError,
-		"ExitNetworkError": ExitNetworkError,
+		"ExitSuccess":       ExitSuccess,
+		"ExitGeneralError":  ExitGeneralError,
+		"ExitConfigError":   ExitConfigError,
+		"ExitCertError":     ExitCertError,
+		"ExitNetworkError":  ExitNetworkError,
+		"ExitExpiryWarning": ExitExpiryWarning,
 	}
 
 	// Check all codes are distinct
@@ -160,6 +161,7 @@ func TestExitCodes(t *testing.T) {
 		{"ExitConfigError", ExitConfigError},
 		{"ExitCertError", ExitCertError},
 		{"ExitNetworkError", ExitNetworkError},
+		{"ExitExpiryWarning", ExitExpiryWarning},
 	}
 
 	for _, tc := range errorCodes {
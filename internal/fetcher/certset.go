@@ -0,0 +1,87 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"sort"
+	"strings"
+)
+
+// CertSetEntry identifies one certificate of a bundle.
+type CertSetEntry struct {
+	Fingerprint string `json:"fingerprint"` // "sha256:<hex>" of the DER encoding
+	Subject     string `json:"subject"`
+}
+
+// CertSet is the normalized content of a PEM bundle: its certificates keyed by
+// fingerprint. Comments, order, line endings and duplicates do not matter, so
+// two bundles with equal sets trust exactly the same roots even when their
+// bytes (and SHA256) differ.
+type CertSet map[string]CertSetEntry
+
+// ParseCertSet returns the set of certificates in a PEM bundle. It counts the
+// same certificates as CountCertificates: CERTIFICATE blocks that parse.
+func ParseCertSet(pemData []byte) CertSet {
+	set := make(CertSet)
+	remaining := pemData
+
+	for {
+		block, rest := pem.Decode(remaining)
+		if block == nil {
+			break
+		}
+		remaining = rest
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		hash := sha256.Sum256(cert.Raw)
+		fingerprint := "sha256:" + hex.EncodeToString(hash[:])
+		set[fingerprint] = CertSetEntry{Fingerprint: fingerprint, Subject: cert.Subject.String()}
+	}
+
+	return set
+}
+
+// Digest returns a hash identifying the set: equal sets have equal digests.
+func (s CertSet) Digest() string {
+	fingerprints := make([]string, 0, len(s))
+	for fingerprint := range s {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+	return ComputeSHA256([]byte(strings.Join(fingerprints, "\n")))
+}
+
+// Diff compares other against s. added holds the certificates only in other,
+// removed those only in s; both are sorted by subject.
+func (s CertSet) Diff(other CertSet) (added, removed []CertSetEntry) {
+	for fingerprint, entry := range other {
+		if _, ok := s[fingerprint]; !ok {
+			added = append(added, entry)
+		}
+	}
+	for fingerprint, entry := range s {
+		if _, ok := other[fingerprint]; !ok {
+			removed = append(removed, entry)
+		}
+	}
+	sortCertSetEntries(added)
+	sortCertSetEntries(removed)
+	return added, removed
+}
+
+func sortCertSetEntries(entries []CertSetEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Subject != entries[j].Subject {
+			return entries[i].Subject < entries[j].Subject
+		}
+		return entries[i].Fingerprint < entries[j].Fingerprint
+	})
+}
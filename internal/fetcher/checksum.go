@@ -0,0 +1,172 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// ChecksumSuffix is appended to a bundle URL to get its published SHA256
+// checksum, as curl.se does for cacert.pem.
+const ChecksumSuffix = ".sha256"
+
+// ChecksumPinned is the checksum source recorded for a checksum given on the
+// command line.
+const ChecksumPinned = "pinned"
+
+// FetchOptions control FetchVerifiedBundle.
+type FetchOptions struct {
+	// SHA256, if set, pins the expected checksum of the bundle.
+	SHA256 string
+	// Cached holds the validators of the bundle already installed from the
+	// same URL. If set, the download is conditional.
+	Cached Validators
+	// CachedURL is the URL Cached belongs to. FetchFromMirrors only sends
+	// Cached to that mirror; FetchVerifiedBundle ignores it.
+	CachedURL string
+	// RequireChecksum lists bundle URLs that must publish a checksum, such as
+	// mirrors one was seen for before. DefaultMozillaBundleURL always must.
+	RequireChecksum []string
+}
+
+// VerifiedBundle is a downloaded bundle together with the checksum it was
+// verified against.
+type VerifiedBundle struct {
+	// NotModified is true if the server reported that the bundle has not
+	// changed since the download described by FetchOptions.Cached. Nothing
+	// was downloaded or verified then, and Data is nil.
+	NotModified bool
+
+	Data []byte
+	// SHA256 is the checksum of Data, which matched every checksum checked.
+	SHA256 string
+	// ChecksumSources lists what Data was checked against: the URL of the
+	// published checksum and/or ChecksumPinned. It is empty if no checksum
+	// was published and none was pinned.
+	ChecksumSources []string
+	// Validators are the cache validators to send with the next download.
+	Validators Validators
+
+	// URL is the mirror that served the bundle, and Attempts every mirror
+	// tried, in order. Both are set by FetchFromMirrors only.
+	URL      string
+	Attempts []MirrorAttempt
+}
+
+// FetchVerifiedBundle downloads the bundle at url and verifies it against the
+// checksum published at url + ChecksumSuffix and, if opts.SHA256 is set,
+// against that pin. Any mismatch returns ErrChecksumMismatch.
+//
+// A custom mirror that does not publish a checksum (404) is accepted; the
+// result then lists only the pin, or no checksum source at all. For
+// DefaultMozillaBundleURL and the URLs in opts.RequireChecksum, a missing
+// checksum returns ErrChecksumMissing instead, so that blocking the checksum
+// request cannot turn verification off.
+func (f *Fetcher) FetchVerifiedBundle(ctx context.Context, url string, opts FetchOptions) (*VerifiedBundle, error) {
+	pin := opts.SHA256
+	if pin != "" {
+		normalized, err := NormalizeChecksum(pin)
+		if err != nil {
+			return nil, err
+		}
+		pin = normalized
+	}
+
+	data, validators, notModified, err := f.fetchBundle(ctx, url, opts.Cached)
+	if err != nil {
+		return nil, err
+	}
+	if notModified {
+		return &VerifiedBundle{NotModified: true, Validators: validators}, nil
+	}
+
+	result := &VerifiedBundle{Data: data, SHA256: ComputeSHA256(data), Validators: validators}
+
+	if pin != "" {
+		if err := VerifyChecksum(data, pin); err != nil {
+			return nil, fmt.Errorf("pinned checksum: %w", err)
+		}
+		result.ChecksumSources = append(result.ChecksumSources, ChecksumPinned)
+	}
+
+	checksumURL := url + ChecksumSuffix
+	published, err := f.FetchChecksum(ctx, checksumURL)
+	switch {
+	case err == nil:
+		if err := VerifyChecksum(data, published); err != nil {
+			return nil, fmt.Errorf("published checksum %s: %w", checksumURL, err)
+		}
+		result.ChecksumSources = append(result.ChecksumSources, checksumURL)
+	case errors.Is(err, errNotPublished):
+		if url == DefaultMozillaBundleURL || slices.Contains(opts.RequireChecksum, url) {
+			return nil, fmt.Errorf("%w at %s, which %s requires", verifierrors.ErrChecksumMissing, checksumURL, url)
+		}
+		// Nothing published next to the bundle; rely on the pin, if any
+	default:
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// errNotPublished is returned by FetchChecksum when the server has no
+// checksum file.
+var errNotPublished = errors.New("checksum not published")
+
+// FetchChecksum downloads a checksum file in sha256sum format
+// ("<hex>  <file>") and returns the checksum in lower-case hex.
+func (f *Fetcher) FetchChecksum(ctx context.Context, url string) (string, error) {
+	// A checksum file is one short line; anything larger is not one
+	resp, err := f.get(ctx, url, nil, 4096)
+	if err != nil {
+		return "", fmt.Errorf("download checksum: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errNotPublished
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("checksum download failed with status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	return ParseChecksumFile(resp.Body)
+}
+
+// ParseChecksumFile returns the checksum from the first line of a file in
+// sha256sum format. A bare checksum is accepted too.
+func ParseChecksumFile(data []byte) (string, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum file is empty")
+	}
+	return NormalizeChecksum(fields[0])
+}
+
+// NormalizeChecksum validates a hex SHA256 checksum, optionally prefixed with
+// "sha256:", and returns it in lower case without the prefix.
+func NormalizeChecksum(checksum string) (string, error) {
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	checksum = strings.TrimPrefix(checksum, "sha256:")
+	if len(checksum) != 64 {
+		return "", fmt.Errorf("invalid SHA256 checksum %q: expected 64 hex characters", checksum)
+	}
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", fmt.Errorf("invalid SHA256 checksum %q: %w", checksum, err)
+	}
+	return checksum, nil
+}
+
+// VerifyChecksum returns ErrChecksumMismatch if the SHA256 of data is not
+// expected, which must be normalized.
+func VerifyChecksum(data []byte, expected string) error {
+	if actual := ComputeSHA256(data); actual != expected {
+		return fmt.Errorf("%w: expected %s, got %s", verifierrors.ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
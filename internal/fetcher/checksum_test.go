@@ -0,0 +1,206 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// checksumServer returns a client serving bundle at url and checksumFile at
+// url + ".sha256"; a nil checksumFile is served as 404.
+func checksumServer(url string, bundle, checksumFile []byte) *mockHTTPClient {
+	return &mockHTTPClient{
+		doFunc: func(req *http.Request) (*http.Response, error) {
+			switch req.URL.String() {
+			case url:
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(bundle))}, nil
+			case url + ChecksumSuffix:
+				if checksumFile == nil {
+					return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(checksumFile))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+}
+
+func TestFetchVerifiedBundle(t *testing.T) {
+	const url = "https://curl.se/ca/cacert.pem"
+	const mirror = "https://mirror.example.com/cacert.pem"
+	bundle := []byte("-----BEGIN CERTIFICATE-----\ntest\n-----END CERTIFICATE-----\n")
+	sum := ComputeSHA256(bundle)
+	wrong := strings.Repeat("0", 64)
+	ctx := context.Background()
+
+	t.Run("published checksum matches", func(t *testing.T) {
+		f := NewFetcher(checksumServer(url, bundle, []byte(sum+"  cacert.pem\n")))
+		result, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, bundle, result.Data)
+		assert.Equal(t, sum, result.SHA256)
+		assert.Equal(t, []string{url + ChecksumSuffix}, result.ChecksumSources)
+	})
+
+	t.Run("published checksum mismatch", func(t *testing.T) {
+		f := NewFetcher(checksumServer(url, bundle, []byte(wrong+"  cacert.pem\n")))
+		_, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, verifierrors.ErrChecksumMismatch))
+	})
+
+	t.Run("pin matches and nothing published", func(t *testing.T) {
+		f := NewFetcher(checksumServer(mirror, bundle, nil))
+		result, err := f.FetchVerifiedBundle(ctx, mirror, FetchOptions{SHA256: "SHA256:" + strings.ToUpper(sum)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ChecksumPinned}, result.ChecksumSources)
+	})
+
+	t.Run("pin mismatch", func(t *testing.T) {
+		f := NewFetcher(checksumServer(url, bundle, []byte(sum)))
+		_, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{SHA256: wrong})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, verifierrors.ErrChecksumMismatch))
+	})
+
+	t.Run("pin and published both checked", func(t *testing.T) {
+		f := NewFetcher(checksumServer(url, bundle, []byte(sum)))
+		result, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{SHA256: sum})
+		require.NoError(t, err)
+		assert.Equal(t, []string{ChecksumPinned, url + ChecksumSuffix}, result.ChecksumSources)
+	})
+
+	t.Run("nothing published or pinned", func(t *testing.T) {
+		f := NewFetcher(checksumServer(mirror, bundle, nil))
+		result, err := f.FetchVerifiedBundle(ctx, mirror, FetchOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, result.ChecksumSources)
+	})
+
+	t.Run("default URL must publish a checksum", func(t *testing.T) {
+		f := NewFetcher(checksumServer(url, bundle, nil))
+		_, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, verifierrors.ErrChecksumMissing))
+
+		// A pin does not make up for the missing checksum
+		_, err = f.FetchVerifiedBundle(ctx, url, FetchOptions{SHA256: sum})
+		assert.True(t, errors.Is(err, verifierrors.ErrChecksumMissing))
+	})
+
+	t.Run("mirror that published a checksum before must still", func(t *testing.T) {
+		f := NewFetcher(checksumServer(mirror, bundle, nil))
+		_, err := f.FetchVerifiedBundle(ctx, mirror, FetchOptions{RequireChecksum: []string{mirror}})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, verifierrors.ErrChecksumMissing))
+	})
+
+	t.Run("invalid pin", func(t *testing.T) {
+		f := NewFetcher(checksumServer(url, bundle, nil))
+		_, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{SHA256: "abc"})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseChecksumFile(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "sha256sum format", data: sum + "  cacert.pem\n", want: sum},
+		{name: "bare checksum", data: sum, want: sum},
+		{name: "upper case", data: strings.ToUpper(sum) + " *cacert.pem", want: sum},
+		{name: "empty", data: "", wantErr: true},
+		{name: "too short", data: "abcd  cacert.pem", wantErr: true},
+		{name: "not hex", data: strings.Repeat("zz", 32), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecksumFile([]byte(tt.data))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFetchVerifiedBundle_Conditional(t *testing.T) {
+	const url = "https://mirror.example.com/cacert.pem"
+	const etag = `"abc123"`
+	const lastModified = "Tue, 09 Sep 2025 03:12:01 GMT"
+	bundle := []byte("-----BEGIN CERTIFICATE-----\ntest\n-----END CERTIFICATE-----\n")
+	ctx := context.Background()
+
+	// The server answers 304 when the client already has the current version
+	client := &mockHTTPClient{
+		doFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.String() != url {
+				return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+			}
+			if req.Header.Get("If-None-Match") == etag || req.Header.Get("If-Modified-Since") == lastModified {
+				return &http.Response{StatusCode: http.StatusNotModified, Body: io.NopCloser(strings.NewReader(""))}, nil
+			}
+			header := http.Header{}
+			header.Set("ETag", etag)
+			header.Set("Last-Modified", lastModified)
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(bundle))}, nil
+		},
+	}
+	f := NewFetcher(client)
+
+	t.Run("first download records validators", func(t *testing.T) {
+		result, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{})
+		require.NoError(t, err)
+		assert.False(t, result.NotModified)
+		assert.Equal(t, bundle, result.Data)
+		assert.Equal(t, Validators{ETag: etag, LastModified: lastModified}, result.Validators)
+	})
+
+	t.Run("unchanged by ETag", func(t *testing.T) {
+		result, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{Cached: Validators{ETag: etag}})
+		require.NoError(t, err)
+		assert.True(t, result.NotModified)
+		assert.Nil(t, result.Data)
+	})
+
+	t.Run("unchanged by Last-Modified", func(t *testing.T) {
+		result, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{Cached: Validators{LastModified: lastModified}})
+		require.NoError(t, err)
+		assert.True(t, result.NotModified)
+	})
+
+	t.Run("stale validators download again", func(t *testing.T) {
+		result, err := f.FetchVerifiedBundle(ctx, url, FetchOptions{Cached: Validators{ETag: `"old"`}})
+		require.NoError(t, err)
+		assert.False(t, result.NotModified)
+		assert.Equal(t, bundle, result.Data)
+	})
+}
+
+func TestFetchMozillaBundle_UnexpectedNotModified(t *testing.T) {
+	// A 304 to an unconditional request is not a bundle
+	client := &mockHTTPClient{
+		doFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNotModified, Status: "304 Not Modified", Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+	_, err := NewFetcher(client).FetchMozillaBundle(context.Background(), DefaultMozillaBundleURL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package fetcher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+)
+
+// NewHTTPClient returns an HTTP client whose TLS connections trust roots
+// instead of the system roots; a nil pool keeps the system roots. Proxies
+// are taken from HTTPS_PROXY, HTTP_PROXY and NO_PROXY (or their lower-case
+// forms), as curl and most other tools do.
+func NewHTTPClient(roots *x509.CertPool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    roots,
+		MinVersion: tls.VersionTLS12,
+	}
+	return &http.Client{Transport: transport}
+}
+
+// UntrustedCertError is returned when a server presents a certificate that
+// does not chain to a trusted root. For a well-known host such as curl.se,
+// the likely cause is a proxy intercepting TLS with its own CA.
+type UntrustedCertError struct {
+	Host string
+	// Issuer is the issuer of the certificate the server presented.
+	Issuer string
+	Err    error
+}
+
+func (e *UntrustedCertError) Error() string {
+	return fmt.Sprintf("certificate presented for %s (issued by %s) is not trusted; a TLS-intercepting proxy is the likely cause: %v", e.Host, e.Issuer, e.Err)
+}
+
+func (e *UntrustedCertError) Unwrap() error {
+	return e.Err
+}
+
+// untrustedCertError returns an UntrustedCertError if err is a failed
+// request to rawURL whose server certificate has an unknown authority.
+func untrustedCertError(rawURL string, err error) error {
+	var unknown x509.UnknownAuthorityError
+	if !errors.As(err, &unknown) {
+		return nil
+	}
+
+	host := rawURL
+	if u, parseErr := neturl.Parse(rawURL); parseErr == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	issuer := "an unknown issuer"
+	if unknown.Cert != nil {
+		issuer = unknown.Cert.Issuer.String()
+	}
+	return &UntrustedCertError{Host: host, Issuer: issuer, Err: err}
+}
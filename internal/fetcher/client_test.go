@@ -0,0 +1,56 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	bundle := GetEmbeddedBundle()
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write(bundle)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	t.Run("trusts the given roots", func(t *testing.T) {
+		roots := x509.NewCertPool()
+		roots.AddCert(server.Certificate())
+
+		data, err := newTestFetcher(NewHTTPClient(roots)).FetchMozillaBundle(ctx, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, bundle, data)
+	})
+
+	t.Run("untrusted certificate points at interception", func(t *testing.T) {
+		requests.Store(0)
+		_, err := newTestFetcher(NewHTTPClient(x509.NewCertPool())).FetchMozillaBundle(ctx, server.URL)
+		require.Error(t, err)
+
+		var untrusted *UntrustedCertError
+		require.True(t, errors.As(err, &untrusted))
+		assert.Equal(t, "127.0.0.1", untrusted.Host)
+		assert.Equal(t, server.Certificate().Issuer.String(), untrusted.Issuer)
+		assert.Contains(t, err.Error(), "TLS-intercepting proxy")
+
+		// Not retried: the handshake fails before any request is served
+		assert.Zero(t, requests.Load())
+	})
+
+	t.Run("untrusted mirror is reported through MirrorError", func(t *testing.T) {
+		f := newTestFetcher(NewHTTPClient(x509.NewCertPool()))
+		_, err := f.FetchFromMirrors(ctx, []string{server.URL, server.URL + "/other"}, FetchOptions{})
+		var untrusted *UntrustedCertError
+		assert.True(t, errors.As(err, &untrusted))
+	})
+}
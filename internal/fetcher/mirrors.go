@@ -0,0 +1,102 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// MirrorAttempt records the outcome of one mirror tried by FetchFromMirrors.
+type MirrorAttempt struct {
+	URL string `json:"url"`
+	// Error is why the mirror was skipped; empty for the mirror that served
+	// the bundle. Err is the same error, for errors.As.
+	Error string `json:"error,omitempty"`
+	Err   error  `json:"-"`
+}
+
+// MirrorError is returned by FetchFromMirrors when no mirror served a bundle.
+type MirrorError struct {
+	Attempts []MirrorAttempt
+}
+
+func (e *MirrorError) Error() string {
+	if len(e.Attempts) == 1 {
+		return e.Attempts[0].Error
+	}
+	failures := make([]string, len(e.Attempts))
+	for i, a := range e.Attempts {
+		failures[i] = fmt.Sprintf("%s: %s", a.URL, a.Error)
+	}
+	return fmt.Sprintf("all %d mirrors failed: %s", len(e.Attempts), strings.Join(failures, "; "))
+}
+
+// Unwrap returns the error of every mirror.
+func (e *MirrorError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		errs = append(errs, a.Err)
+	}
+	return errs
+}
+
+// FetchFromMirrors downloads and verifies the bundle from the first of urls
+// that serves it, using FetchVerifiedBundle with its retries for each. The
+// result records the mirror that served it in URL and every mirror tried in
+// Attempts.
+//
+// opts.Cached is only sent to opts.CachedURL, the mirror the installed bundle
+// came from. A checksum mismatch or a missing required checksum is not a
+// mirror failure and aborts at once: the mirror served a bundle, just not
+// one that could be verified.
+func (f *Fetcher) FetchFromMirrors(ctx context.Context, urls []string, opts FetchOptions) (*VerifiedBundle, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no bundle URL configured")
+	}
+
+	var attempts []MirrorAttempt
+	for _, url := range urls {
+		mirrorOpts := opts
+		if url != opts.CachedURL {
+			mirrorOpts.Cached = Validators{}
+		}
+
+		result, err := f.fetchMirror(ctx, url, mirrorOpts)
+		if err == nil {
+			result.URL = url
+			result.Attempts = append(attempts, MirrorAttempt{URL: url})
+			return result, nil
+		}
+		if errors.Is(err, verifierrors.ErrChecksumMismatch) || errors.Is(err, verifierrors.ErrChecksumMissing) || ctx.Err() != nil {
+			return nil, fmt.Errorf("%s: %w", url, err)
+		}
+		attempts = append(attempts, MirrorAttempt{URL: url, Error: err.Error(), Err: err})
+	}
+
+	return nil, &MirrorError{Attempts: attempts}
+}
+
+// fetchMirror runs FetchVerifiedBundle for one mirror within MirrorTimeout.
+func (f *Fetcher) fetchMirror(ctx context.Context, url string, opts FetchOptions) (*VerifiedBundle, error) {
+	if f.MirrorTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.MirrorTimeout)
+		defer cancel()
+	}
+	return f.FetchVerifiedBundle(ctx, url, opts)
+}
+
+// Failed returns the mirrors that were tried and skipped before one served
+// the bundle.
+func (b *VerifiedBundle) Failed() []MirrorAttempt {
+	var failed []MirrorAttempt
+	for _, a := range b.Attempts {
+		if a.Error != "" {
+			failed = append(failed, a)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,214 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// newTestFetcher returns a fetcher that retries without waiting.
+func newTestFetcher(client HTTPClient) *Fetcher {
+	f := NewFetcher(client)
+	f.Backoff = time.Millisecond
+	return f
+}
+
+// countingServer serves responses by URL and counts the requests to each.
+type countingServer struct {
+	mu       sync.Mutex
+	requests map[string]int
+	handle   func(url string, n int) (*http.Response, error)
+}
+
+func (s *countingServer) Do(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	if s.requests == nil {
+		s.requests = make(map[string]int)
+	}
+	url := req.URL.String()
+	s.requests[url]++
+	n := s.requests[url]
+	s.mu.Unlock()
+	return s.handle(url, n)
+}
+
+func statusResponse(code int) *http.Response {
+	return &http.Response{StatusCode: code, Status: http.StatusText(code), Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func bodyResponse(body []byte) *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(body))}
+}
+
+func TestGet_Retries(t *testing.T) {
+	const url = "https://mirror.example.com/cacert.pem"
+	bundle := []byte("-----BEGIN CERTIFICATE-----\ntest\n-----END CERTIFICATE-----\n")
+	ctx := context.Background()
+
+	t.Run("transient failures are retried", func(t *testing.T) {
+		server := &countingServer{handle: func(u string, n int) (*http.Response, error) {
+			switch {
+			case strings.HasSuffix(u, ChecksumSuffix):
+				return statusResponse(http.StatusNotFound), nil
+			case n == 1:
+				return nil, errors.New("connection reset")
+			case n == 2:
+				return statusResponse(http.StatusServiceUnavailable), nil
+			}
+			return bodyResponse(bundle), nil
+		}}
+		data, err := newTestFetcher(server).FetchMozillaBundle(ctx, url)
+		require.NoError(t, err)
+		assert.Equal(t, bundle, data)
+		assert.Equal(t, 3, server.requests[url])
+	})
+
+	t.Run("gives up after the configured attempts", func(t *testing.T) {
+		server := &countingServer{handle: func(string, int) (*http.Response, error) {
+			return statusResponse(http.StatusBadGateway), nil
+		}}
+		f := newTestFetcher(server)
+		f.Attempts = 4
+		_, err := f.FetchMozillaBundle(ctx, url)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "download failed with status 502")
+		assert.Equal(t, 4, server.requests[url])
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		server := &countingServer{handle: func(string, int) (*http.Response, error) {
+			return statusResponse(http.StatusForbidden), nil
+		}}
+		_, err := newTestFetcher(server).FetchMozillaBundle(ctx, url)
+		require.Error(t, err)
+		assert.Equal(t, 1, server.requests[url])
+	})
+
+	t.Run("oversized response is rejected", func(t *testing.T) {
+		server := &countingServer{handle: func(string, int) (*http.Response, error) {
+			return bodyResponse(bundle), nil
+		}}
+		f := newTestFetcher(server)
+		f.MaxSize = int64(len(bundle)) - 1
+		_, err := f.FetchMozillaBundle(ctx, url)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "byte limit")
+		assert.Equal(t, 1, server.requests[url])
+
+		// A body of exactly MaxSize is fine
+		f.MaxSize = int64(len(bundle))
+		_, err = f.FetchMozillaBundle(ctx, url)
+		assert.NoError(t, err)
+	})
+}
+
+func TestFetchFromMirrors(t *testing.T) {
+	const mirrorA = "https://a.example.com/cacert.pem"
+	const mirrorB = "https://b.example.com/cacert.pem"
+	bundle := []byte("-----BEGIN CERTIFICATE-----\ntest\n-----END CERTIFICATE-----\n")
+	sum := ComputeSHA256(bundle)
+	ctx := context.Background()
+
+	t.Run("falls back to the next mirror", func(t *testing.T) {
+		server := &countingServer{handle: func(u string, n int) (*http.Response, error) {
+			switch u {
+			case mirrorA:
+				return statusResponse(http.StatusServiceUnavailable), nil
+			case mirrorB:
+				return bodyResponse(bundle), nil
+			}
+			return statusResponse(http.StatusNotFound), nil
+		}}
+		result, err := newTestFetcher(server).FetchFromMirrors(ctx, []string{mirrorA, mirrorB}, FetchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, mirrorB, result.URL)
+		assert.Equal(t, bundle, result.Data)
+		require.Len(t, result.Attempts, 2)
+		assert.Equal(t, mirrorA, result.Attempts[0].URL)
+		assert.Contains(t, result.Attempts[0].Error, "503")
+		assert.Equal(t, []MirrorAttempt{result.Attempts[0]}, result.Failed())
+		assert.Equal(t, DefaultAttempts, server.requests[mirrorA])
+	})
+
+	t.Run("all mirrors failing", func(t *testing.T) {
+		server := &countingServer{handle: func(string, int) (*http.Response, error) {
+			return statusResponse(http.StatusNotFound), nil
+		}}
+		_, err := newTestFetcher(server).FetchFromMirrors(ctx, []string{mirrorA, mirrorB}, FetchOptions{})
+		var mirrorErr *MirrorError
+		require.True(t, errors.As(err, &mirrorErr))
+		assert.Len(t, mirrorErr.Attempts, 2)
+		assert.Contains(t, err.Error(), "all 2 mirrors failed")
+	})
+
+	t.Run("checksum mismatch does not fall back", func(t *testing.T) {
+		server := &countingServer{handle: func(u string, n int) (*http.Response, error) {
+			if u == mirrorA+ChecksumSuffix {
+				return bodyResponse([]byte(strings.Repeat("0", 64))), nil
+			}
+			if strings.HasSuffix(u, ChecksumSuffix) {
+				return statusResponse(http.StatusNotFound), nil
+			}
+			return bodyResponse(bundle), nil
+		}}
+		_, err := newTestFetcher(server).FetchFromMirrors(ctx, []string{mirrorA, mirrorB}, FetchOptions{})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, verifierrors.ErrChecksumMismatch))
+		assert.Zero(t, server.requests[mirrorB])
+	})
+
+	t.Run("validators only go to the cached mirror", func(t *testing.T) {
+		conditional := make(map[string]bool)
+		client := &mockHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("If-None-Match") != "" {
+				conditional[req.URL.String()] = true
+			}
+			if strings.HasSuffix(req.URL.String(), ChecksumSuffix) {
+				return bodyResponse([]byte(sum)), nil
+			}
+			return bodyResponse(bundle), nil
+		}}
+		opts := FetchOptions{Cached: Validators{ETag: `"1"`}, CachedURL: mirrorB}
+		_, err := newTestFetcher(client).FetchFromMirrors(ctx, []string{mirrorA}, opts)
+		require.NoError(t, err)
+		assert.False(t, conditional[mirrorA])
+
+		_, err = newTestFetcher(client).FetchFromMirrors(ctx, []string{mirrorB}, opts)
+		require.NoError(t, err)
+		assert.True(t, conditional[mirrorB])
+	})
+
+	t.Run("hanging mirror times out", func(t *testing.T) {
+		client := &mockHTTPClient{doFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.String() == mirrorA {
+				<-req.Context().Done()
+				return nil, req.Context().Err()
+			}
+			if strings.HasSuffix(req.URL.String(), ChecksumSuffix) {
+				return statusResponse(http.StatusNotFound), nil
+			}
+			return bodyResponse(bundle), nil
+		}}
+		f := newTestFetcher(client)
+		f.MirrorTimeout = 20 * time.Millisecond
+		result, err := f.FetchFromMirrors(ctx, []string{mirrorA, mirrorB}, FetchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, mirrorB, result.URL)
+	})
+
+	t.Run("no mirrors", func(t *testing.T) {
+		_, err := newTestFetcher(nil).FetchFromMirrors(ctx, nil, FetchOptions{})
+		assert.Error(t, err)
+	})
+}
@@ -3,8 +3,8 @@ package fetcher
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
+	"time"
 )
 
 const (
@@ -15,52 +15,91 @@ const (
 // Fetcher handles downloading Mozilla CA bundles.
 type Fetcher struct {
 	client HTTPClient
+
+	// MaxSize is the largest response body accepted, in bytes.
+	MaxSize int64
+	// Attempts is how often a download is tried before giving up on a URL.
+	// Network errors, 5xx and 429 responses are retried; 4xx are not.
+	Attempts int
+	// Backoff is the delay before the first retry; it doubles with every
+	// further retry, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MirrorTimeout bounds the time FetchFromMirrors spends on one mirror,
+	// retries included, so a hanging mirror does not use up the caller's
+	// whole deadline. Zero means no limit.
+	MirrorTimeout time.Duration
 }
 
-// NewFetcher creates a new Fetcher with the given HTTP client.
+// NewFetcher creates a new Fetcher with the given HTTP client and the
+// default size limit and retry policy.
 // If client is nil, uses http.DefaultClient.
 func NewFetcher(client HTTPClient) *Fetcher {
 	if client == nil {
 		client = http.DefaultClient
 	}
 	return &Fetcher{
-		client: client,
+		client:        client,
+		MaxSize:       DefaultMaxSize,
+		Attempts:      DefaultAttempts,
+		Backoff:       DefaultBackoff,
+		MaxBackoff:    DefaultMaxBackoff,
+		MirrorTimeout: DefaultMirrorTimeout,
 	}
 }
 
+// Validators are the HTTP cache validators of a downloaded bundle. Sent back
+// with the next download, they let the server answer that nothing changed.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// IsZero reports whether no validators are set.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
 // FetchMozillaBundle downloads the Mozilla CA bundle from the specified URL.
 // The context can be used to cancel the download or set a timeout.
 func (f *Fetcher) FetchMozillaBundle(ctx context.Context, url string) ([]byte, error) {
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
+	data, _, _, err := f.fetchBundle(ctx, url, Validators{})
+	return data, err
+}
 
-	// Set User-Agent to identify ourselves
-	req.Header.Set("User-Agent", "verifi/1.0 (certificate management tool)")
+// fetchBundle downloads the bundle at url. With cached validators, the
+// request is conditional and notModified is true if the server answered 304
+// Not Modified; data is nil then.
+func (f *Fetcher) fetchBundle(ctx context.Context, url string, cached Validators) (data []byte, validators Validators, notModified bool, err error) {
+	header := http.Header{}
+	if cached.ETag != "" {
+		header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		header.Set("If-Modified-Since", cached.LastModified)
+	}
 
-	// Execute request
-	resp, err := f.client.Do(req)
+	resp, err := f.get(ctx, url, header, f.MaxSize)
 	if err != nil {
-		return nil, fmt.Errorf("download bundle: %w", err)
+		return nil, Validators{}, false, fmt.Errorf("download bundle: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotModified && !cached.IsZero() {
+		return nil, cached, true, nil
 	}
-	defer func() { _ = resp.Body.Close() }() // Ignore close error - standard practice
 
 	// Check HTTP status
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, resp.Status)
+		return nil, Validators{}, false, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, resp.Status)
 	}
 
-	// Read response body
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+	if len(resp.Body) == 0 {
+		return nil, Validators{}, false, fmt.Errorf("downloaded bundle is empty")
 	}
 
-	if len(data) == 0 {
-		return nil, fmt.Errorf("downloaded bundle is empty")
+	validators = Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
 	}
-
-	return data, nil
+	return resp.Body, validators, false, nil
 }
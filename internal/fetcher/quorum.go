@@ -0,0 +1,118 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// SourceResult is what one source returned to FetchQuorum.
+type SourceResult struct {
+	URL string
+	// Bundle and Certs are set if the source served a bundle that passed its
+	// checksum checks; Err is set otherwise.
+	Bundle *VerifiedBundle
+	Certs  CertSet
+	Err    error
+}
+
+// QuorumResult is the outcome of FetchQuorum.
+type QuorumResult struct {
+	Quorum int
+	// Sources holds every source, in the order given.
+	Sources []SourceResult
+	// Reference is the index in Sources of the bundle the others are compared
+	// with: the first source of the largest group serving the same
+	// certificate set, or -1 if no source served a bundle.
+	Reference int
+	// Agreeing lists the URLs of the sources whose certificate set equals the
+	// reference's, the reference included.
+	Agreeing []string
+}
+
+// Accepted returns the reference bundle if at least Quorum sources agree on
+// its certificates, or nil.
+func (r *QuorumResult) Accepted() *VerifiedBundle {
+	if r.Reference < 0 || len(r.Agreeing) < r.Quorum {
+		return nil
+	}
+	return r.Sources[r.Reference].Bundle
+}
+
+// ReferenceCerts returns the certificate set of the reference bundle.
+func (r *QuorumResult) ReferenceCerts() CertSet {
+	if r.Reference < 0 {
+		return nil
+	}
+	return r.Sources[r.Reference].Certs
+}
+
+// FetchQuorum downloads the bundle from every one of urls and accepts it only
+// if at least quorum of them serve the same certificate set (see CertSet), so
+// that a single compromised source cannot change what is trusted. Each
+// source is fetched as by FetchVerifiedBundle, retries and checksum checks
+// included; a source that fails, or whose bundle holds no parseable
+// certificates, does not count. Conditional requests are not
+// made, since every source must be compared.
+//
+// The result describes every source and is returned even with an error, so
+// callers can report where the sources differ. The error wraps
+// ErrSourcesDisagree if enough sources served a bundle but too few agreed.
+func (f *Fetcher) FetchQuorum(ctx context.Context, urls []string, quorum int, opts FetchOptions) (*QuorumResult, error) {
+	if quorum < 1 || quorum > len(urls) {
+		return nil, fmt.Errorf("quorum %d needs between 1 and %d sources", quorum, len(urls))
+	}
+	opts.Cached = Validators{}
+
+	result := &QuorumResult{Quorum: quorum, Reference: -1}
+	served := 0
+	for _, url := range urls {
+		source := SourceResult{URL: url}
+		source.Bundle, source.Err = f.fetchMirror(ctx, url, opts)
+		if source.Err == nil {
+			source.Bundle.URL = url
+			source.Certs = ParseCertSet(source.Bundle.Data)
+			// Sources serving nothing usable must not agree with each other
+			if len(source.Certs) == 0 {
+				source.Bundle, source.Err = nil, fmt.Errorf("%s: no parseable certificates in bundle", url)
+			} else {
+				served++
+			}
+		}
+		result.Sources = append(result.Sources, source)
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+	}
+
+	// The largest group of identical sets is the reference; on a tie, the
+	// one whose first source comes first
+	digests := make([]string, len(result.Sources))
+	counts := make(map[string]int)
+	for i, source := range result.Sources {
+		if source.Err == nil {
+			digests[i] = source.Certs.Digest()
+			counts[digests[i]]++
+		}
+	}
+	for i, source := range result.Sources {
+		if source.Err == nil && (result.Reference < 0 || counts[digests[i]] > counts[digests[result.Reference]]) {
+			result.Reference = i
+		}
+	}
+	for i, source := range result.Sources {
+		if source.Err == nil && result.Reference >= 0 && digests[i] == digests[result.Reference] {
+			result.Agreeing = append(result.Agreeing, source.URL)
+		}
+	}
+
+	switch {
+	case served < quorum:
+		return result, fmt.Errorf("only %d of %d sources served a bundle, %d must agree", served, len(urls), quorum)
+	case len(result.Agreeing) < quorum:
+		return result, fmt.Errorf("%w: at most %d of %d sources serve the same certificates, %d must agree",
+			verifierrors.ErrSourcesDisagree, len(result.Agreeing), len(urls), quorum)
+	}
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	verifierrors "github.com/princespaghetti/verifi/internal/errors"
+)
+
+// embeddedCerts returns the certificates of the embedded bundle as separate
+// PEM blocks.
+func embeddedCerts(t *testing.T) [][]byte {
+	t.Helper()
+	var blocks [][]byte
+	rest := GetEmbeddedBundle()
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, pem.EncodeToMemory(block))
+	}
+	require.Greater(t, len(blocks), 2)
+	return blocks
+}
+
+func joinBlocks(blocks [][]byte) []byte {
+	var out []byte
+	for _, block := range blocks {
+		out = append(out, block...)
+	}
+	return out
+}
+
+func TestCertSet(t *testing.T) {
+	blocks := embeddedCerts(t)
+	full := ParseCertSet(GetEmbeddedBundle())
+	assert.Len(t, full, CountCertificates(GetEmbeddedBundle()))
+
+	// Order, comments and duplicates do not change the set
+	reordered := make([][]byte, 0, len(blocks)+1)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		reordered = append(reordered, blocks[i])
+	}
+	reordered = append(reordered, blocks[0])
+	same := ParseCertSet(joinBlocks(reordered))
+	assert.Equal(t, full.Digest(), same.Digest())
+	added, removed := full.Diff(same)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+
+	// Dropping a root shows up as removed, and the other way round as added
+	smaller := ParseCertSet(joinBlocks(blocks[1:]))
+	assert.NotEqual(t, full.Digest(), smaller.Digest())
+	added, removed = full.Diff(smaller)
+	assert.Empty(t, added)
+	require.Len(t, removed, 1)
+	assert.True(t, strings.HasPrefix(removed[0].Fingerprint, "sha256:"))
+	assert.NotEmpty(t, removed[0].Subject)
+
+	added, removed = smaller.Diff(full)
+	assert.Len(t, added, 1)
+	assert.Empty(t, removed)
+
+	assert.Empty(t, ParseCertSet([]byte("not a bundle")))
+}
+
+func TestFetchQuorum(t *testing.T) {
+	const curl = "https://curl.se/ca/cacert.pem"
+	const mirror = "https://mirror.example.com/cacert.pem"
+	const other = "https://other.example.com/cacert.pem"
+	blocks := embeddedCerts(t)
+	bundle := GetEmbeddedBundle()
+	reformatted := joinBlocks(blocks)  // same roots, different bytes
+	tampered := joinBlocks(blocks[1:]) // one root missing
+	ctx := context.Background()
+
+	// Every bundle served has its checksum published next to it
+	serve := func(bundles map[string][]byte) *countingServer {
+		return &countingServer{handle: func(u string, n int) (*http.Response, error) {
+			if data, ok := bundles[u]; ok {
+				return bodyResponse(data), nil
+			}
+			if data, ok := bundles[strings.TrimSuffix(u, ChecksumSuffix)]; ok {
+				return bodyResponse([]byte(ComputeSHA256(data))), nil
+			}
+			return statusResponse(http.StatusNotFound), nil
+		}}
+	}
+
+	t.Run("sources agree on the certificates", func(t *testing.T) {
+		f := newTestFetcher(serve(map[string][]byte{curl: bundle, mirror: reformatted, other: tampered}))
+		result, err := f.FetchQuorum(ctx, []string{curl, mirror, other}, 2, FetchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{curl, mirror}, result.Agreeing)
+		require.NotNil(t, result.Accepted())
+		assert.Equal(t, bundle, result.Accepted().Data)
+		assert.Equal(t, curl, result.Accepted().URL)
+	})
+
+	t.Run("sources disagree", func(t *testing.T) {
+		f := newTestFetcher(serve(map[string][]byte{curl: bundle, mirror: tampered}))
+		result, err := f.FetchQuorum(ctx, []string{curl, mirror}, 2, FetchOptions{})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, verifierrors.ErrSourcesDisagree))
+		require.NotNil(t, result)
+		assert.Nil(t, result.Accepted())
+		assert.Equal(t, 0, result.Reference)
+
+		_, removed := result.ReferenceCerts().Diff(result.Sources[1].Certs)
+		assert.Len(t, removed, 1)
+	})
+
+	t.Run("largest group is the reference", func(t *testing.T) {
+		f := newTestFetcher(serve(map[string][]byte{curl: tampered, mirror: bundle, other: reformatted}))
+		result, err := f.FetchQuorum(ctx, []string{curl, mirror, other}, 2, FetchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Reference)
+		assert.Equal(t, []string{mirror, other}, result.Agreeing)
+	})
+
+	t.Run("too few sources served a bundle", func(t *testing.T) {
+		f := newTestFetcher(serve(map[string][]byte{curl: bundle}))
+		result, err := f.FetchQuorum(ctx, []string{curl, mirror}, 2, FetchOptions{})
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, verifierrors.ErrSourcesDisagree))
+		require.Len(t, result.Sources, 2)
+		assert.Error(t, result.Sources[1].Err)
+	})
+
+	t.Run("sources without certificates do not count", func(t *testing.T) {
+		portal := []byte("<html>Sign in to continue</html>")
+		f := newTestFetcher(serve(map[string][]byte{curl: bundle, mirror: portal, other: portal}))
+		result, err := f.FetchQuorum(ctx, []string{curl, mirror, other}, 2, FetchOptions{})
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, verifierrors.ErrSourcesDisagree))
+		assert.Nil(t, result.Accepted())
+		assert.Equal(t, 0, result.Reference)
+		assert.Equal(t, []string{curl}, result.Agreeing)
+		assert.Error(t, result.Sources[1].Err)
+		assert.Error(t, result.Sources[2].Err)
+		assert.Nil(t, result.Sources[1].Bundle)
+	})
+
+	t.Run("vendored file source", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cacert.pem")
+		require.NoError(t, os.WriteFile(path, reformatted, 0644))
+		vendored := "file://" + filepath.ToSlash(path)
+
+		f := newTestFetcher(serve(map[string][]byte{curl: bundle}))
+		result, err := f.FetchQuorum(ctx, []string{curl, vendored}, 2, FetchOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{curl, vendored}, result.Agreeing)
+	})
+
+	t.Run("invalid quorum", func(t *testing.T) {
+		f := newTestFetcher(serve(nil))
+		_, err := f.FetchQuorum(ctx, []string{curl}, 2, FetchOptions{})
+		assert.Error(t, err)
+		_, err = f.FetchQuorum(ctx, []string{curl}, 0, FetchOptions{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is the largest bundle accepted by default. The Mozilla
+	// bundle is about 250KB; anything far larger is not a CA bundle.
+	DefaultMaxSize = 16 << 20
+
+	// DefaultAttempts is how often a download is tried before giving up on
+	// a URL.
+	DefaultAttempts = 3
+
+	// DefaultBackoff is the delay before the first retry. It doubles with
+	// every further retry, up to DefaultMaxBackoff.
+	DefaultBackoff    = 250 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+
+	// DefaultMirrorTimeout is how long FetchFromMirrors waits for one mirror
+	// before moving on to the next.
+	DefaultMirrorTimeout = 30 * time.Second
+)
+
+// response is a fully read HTTP response.
+type response struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+// retryable reports whether a response with this status may succeed if the
+// request is repeated: server errors and rate limiting.
+func retryable(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// get performs a GET request for url, reading at most limit bytes of the
+// body. Network errors, read errors, 5xx and 429 responses are retried up to
+// f.Attempts times with exponential backoff; the last response or error is
+// returned. Other responses, including 4xx, are returned as they are for the
+// caller to interpret. A body larger than limit is an error that is not
+// retried. file:// URLs are read from disk, once.
+func (f *Fetcher) get(ctx context.Context, url string, header http.Header, limit int64) (*response, error) {
+	if strings.HasPrefix(url, "file://") {
+		return getFile(url, limit)
+	}
+
+	attempts := f.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := f.Backoff
+
+	for attempt := 1; ; attempt++ {
+		resp, transient, err := f.getOnce(ctx, url, header, limit)
+
+		// Give up on permanent failures, cancellation and the last attempt
+		if !transient || ctx.Err() != nil || attempt == attempts {
+			if err != nil && transient && attempt > 1 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt)
+			}
+			return resp, err
+		}
+
+		wait := delay
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header); ok {
+				wait = after
+			}
+		}
+		if f.MaxBackoff > 0 && wait > f.MaxBackoff {
+			wait = f.MaxBackoff
+		}
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+			return resp, err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// getOnce performs a single attempt of get. transient reports whether the
+// attempt may be retried.
+func (f *Fetcher) getOnce(ctx context.Context, url string, header http.Header, limit int64) (resp *response, transient bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("create request: %w", err)
+	}
+
+	// Set User-Agent to identify ourselves
+	req.Header.Set("User-Agent", "verifi/1.0 (certificate management tool)")
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	httpResp, err := f.client.Do(req)
+	if err != nil {
+		// An untrusted certificate will not become trusted by retrying
+		if untrusted := untrustedCertError(url, err); untrusted != nil {
+			return nil, false, untrusted
+		}
+		return nil, true, err
+	}
+	defer func() { _ = httpResp.Body.Close() }() // Ignore close error - standard practice
+
+	resp = &response{
+		StatusCode: httpResp.StatusCode,
+		Status:     httpResp.Status,
+		Header:     httpResp.Header,
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	if retryable(resp.StatusCode) {
+		return resp, true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, false, nil
+	}
+
+	// Read one byte past the limit to tell a full-sized body from a larger one
+	body, err := io.ReadAll(io.LimitReader(httpResp.Body, limit+1))
+	if err != nil {
+		return nil, true, fmt.Errorf("read response: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, false, fmt.Errorf("response from %s exceeds the %d byte limit", url, limit)
+	}
+	resp.Body = body
+	return resp, false, nil
+}
+
+// getFile reads a file:// URL, such as a bundle vendored into a repository,
+// as if it had been served over HTTP: a missing file is a 404.
+func getFile(rawURL string, limit int64) (*response, error) {
+	u, err := neturl.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return nil, fmt.Errorf("invalid file URL %q", rawURL)
+	}
+
+	file, err := os.Open(filepath.FromSlash(u.Path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Header: http.Header{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response from %s exceeds the %d byte limit", rawURL, limit)
+	}
+	return &response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{}, Body: body}, nil
+}
+
+// retryAfter parses a Retry-After header given in seconds.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
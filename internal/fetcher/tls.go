@@ -0,0 +1,92 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultTLSPort is used when a target does not specify a port.
+	DefaultTLSPort = "443"
+)
+
+// ParseTarget normalizes a TLS target into a dialable host:port address and
+// the bare host name. It accepts "host", "host:port" and URLs such as
+// "https://host:8443/path".
+func ParseTarget(target string) (addr, host string, err error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", "", fmt.Errorf("empty target")
+	}
+
+	// Strip scheme and path from URLs
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return "", "", fmt.Errorf("parse target URL: %w", err)
+		}
+		if u.Host == "" {
+			return "", "", fmt.Errorf("target URL %q has no host", target)
+		}
+		target = u.Host
+	}
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		// No port given (IPv6 literals may still carry brackets)
+		host = strings.Trim(target, "[]")
+		port = DefaultTLSPort
+	}
+	if host == "" {
+		return "", "", fmt.Errorf("target %q has no host", target)
+	}
+
+	return net.JoinHostPort(host, port), host, nil
+}
+
+// FetchServerChain performs a TLS handshake with addr and returns the
+// certificate chain presented by the server, leaf first.
+//
+// The server is deliberately NOT verified: the point is to capture a chain
+// that the local trust store does not (yet) trust, such as a TLS-intercepting
+// proxy's CA. Callers must treat the result as untrusted input.
+// serverName sets the SNI value; if empty, the host part of addr is used.
+func FetchServerChain(ctx context.Context, addr, serverName string) ([]*x509.Certificate, error) {
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("parse address: %w", err)
+		}
+		serverName = host
+	}
+
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			ServerName:         serverName,
+			InsecureSkipVerify: true, // #nosec G402 -- chain is captured for inspection, not trusted
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake with %s: %w", addr, err)
+	}
+	defer func() { _ = conn.Close() }() // Ignore close error - handshake already completed
+
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return nil, fmt.Errorf("unexpected connection type %T", conn)
+	}
+
+	chain := tlsConn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("server %s presented no certificates", addr)
+	}
+
+	return chain, nil
+}
@@ -0,0 +1,76 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		target   string
+		wantAddr string
+		wantHost string
+		wantErr  bool
+	}{
+		{target: "proxy.corp.com", wantAddr: "proxy.corp.com:443", wantHost: "proxy.corp.com"},
+		{target: "proxy.corp.com:8443", wantAddr: "proxy.corp.com:8443", wantHost: "proxy.corp.com"},
+		{target: "https://proxy.corp.com/path?q=1", wantAddr: "proxy.corp.com:443", wantHost: "proxy.corp.com"},
+		{target: "https://proxy.corp.com:8443", wantAddr: "proxy.corp.com:8443", wantHost: "proxy.corp.com"},
+		{target: "[::1]:8443", wantAddr: "[::1]:8443", wantHost: "::1"},
+		{target: "", wantErr: true},
+		{target: "https://", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			addr, host, err := ParseTarget(tt.target)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAddr, addr)
+			assert.Equal(t, tt.wantHost, host)
+		})
+	}
+}
+
+func TestFetchServerChain(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("returns presented chain", func(t *testing.T) {
+		chain, err := FetchServerChain(ctx, addr, "")
+		require.NoError(t, err)
+		require.NotEmpty(t, chain)
+		assert.True(t, chain[0].Equal(server.Certificate()))
+	})
+
+	t.Run("SNI override", func(t *testing.T) {
+		chain, err := FetchServerChain(ctx, addr, "example.com")
+		require.NoError(t, err)
+		require.NotEmpty(t, chain)
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		closed := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		closedAddr := strings.TrimPrefix(closed.URL, "https://")
+		closed.Close()
+
+		_, err := FetchServerChain(ctx, closedAddr, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "TLS handshake")
+	})
+}